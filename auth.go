@@ -0,0 +1,70 @@
+package bind
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+)
+
+// Credentials holds a parsed Authorization header. Username and Password are
+// only set for the Basic scheme, Token holds the credentials of other schemes,
+// e.g. the token of a Bearer header.
+type Credentials struct {
+	Scheme   string
+	Token    string
+	Username string
+	Password string
+}
+
+// IsBearer reports whether c uses the Bearer scheme.
+func (c *Credentials) IsBearer() bool {
+	return strings.EqualFold(c.Scheme, "Bearer")
+}
+
+// IsBasic reports whether c uses the Basic scheme.
+func (c *Credentials) IsBasic() bool {
+	return strings.EqualFold(c.Scheme, "Basic")
+}
+
+// Authorization parses the Authorization header of r. Basic credentials are
+// base64 decoded:
+//
+//	creds, err := bind.Authorization(r)
+//	if err != nil {
+//		http.Error(w, err.Error(), bind.StatusCode(err))
+//		return
+//	}
+//	if !creds.IsBearer() || !validToken(creds.Token) {
+//		...
+//	}
+//
+// A missing or malformed header results in an *AuthorizationError.
+func Authorization(r *http.Request) (*Credentials, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, &AuthorizationError{Reason: "missing authorization header"}
+	}
+	scheme, token, _ := strings.Cut(header, " ")
+	token = strings.TrimSpace(token)
+	if scheme == "" || token == "" {
+		return nil, &AuthorizationError{Reason: "malformed authorization header"}
+	}
+
+	c := &Credentials{Scheme: scheme}
+	if !c.IsBasic() {
+		c.Token = token
+		return c, nil
+	}
+
+	decoded, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return nil, &AuthorizationError{Reason: "malformed basic credentials"}
+	}
+	username, password, ok := strings.Cut(string(decoded), ":")
+	if !ok {
+		return nil, &AuthorizationError{Reason: "malformed basic credentials"}
+	}
+	c.Username = username
+	c.Password = password
+	return c, nil
+}
@@ -0,0 +1,48 @@
+package bind
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestAuthorization(t *testing.T) {
+	tests := []struct {
+		header string
+		want   *Credentials
+	}{
+		{"Bearer abc.def", &Credentials{Scheme: "Bearer", Token: "abc.def"}},
+		{"bearer  abc", &Credentials{Scheme: "bearer", Token: "abc"}},
+		{"Basic dXNlcjpwYXNzOndvcmQ=", &Credentials{Scheme: "Basic", Username: "user", Password: "pass:word"}},
+		{"ApiKey xyz", &Credentials{Scheme: "ApiKey", Token: "xyz"}},
+		{"", nil},
+		{"Bearer", nil},
+		{"Basic !!!", nil},
+		{"Basic dXNlcg==", nil},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		if test.header != "" {
+			r.Header.Set("Authorization", test.header)
+		}
+		got, err := Authorization(r)
+		if test.want == nil {
+			if !errors.Is(err, ErrInvalidAuthorization) || StatusCode(err) != http.StatusUnauthorized {
+				t.Errorf("%q: got %v, want *AuthorizationError", test.header, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.header, err)
+		} else if *got != *test.want {
+			t.Errorf("%q: got %+v, want %+v", test.header, got, test.want)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.SetBasicAuth("u", "p")
+	if c, err := Authorization(r); err != nil || !c.IsBasic() || c.IsBearer() {
+		t.Errorf("got %+v, %v", c, err)
+	}
+}
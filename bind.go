@@ -2,14 +2,25 @@
 package bind
 
 import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"database/sql"
+	"encoding"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
-	"encoding/xml"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/url"
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-playground/form/v4"
 )
@@ -19,162 +30,1716 @@ type Flag int
 const (
 	// When the Vacuum flag is set, url.Values is cleaned before trying to bind the values.
 	// Strings are trimmed, empty strings and zero length slices are deleted.
+	// Values from default struct tags are filled in after vacuuming.
 	Vacuum Flag = iota
+	// When the Strict flag is set, a JSON, YAML or TOML body with fields that
+	// can't be mapped to the destination value is rejected. Note that this is
+	// not supported for XML bodies.
+	Strict
+	// When the NoBodyLimit flag is set, MaxBodyBytes is not enforced.
+	NoBodyLimit
+	// When the Trim flag is set, url.Values strings are trimmed before trying
+	// to bind the values. Unlike Vacuum, empty strings are kept.
+	Trim
+	// When the CaseInsensitive flag is set, url.Values keys match struct tag
+	// names regardless of case.
+	CaseInsensitive
+	// When the UseNumber flag is set, numbers in a JSON body are decoded into
+	// interface fields as a json.Number instead of a float64. This avoids
+	// precision loss for large integers.
+	UseNumber
+	// When the IgnoreUnsupportedMediaType flag is set, a body with an
+	// unsupported content type is ignored instead of rejected with
+	// ErrUnsupportedMediaType.
+	IgnoreUnsupportedMediaType
+	// When the SplitHeaders flag is set, header values bound to a slice field
+	// are split on commas, e.g. X-Forwarded-For: a, b results in two elements.
+	// Quoted commas are not taken into account.
+	SplitHeaders
+	// When the NoZeroDefault flag is set, an empty path value leaves a
+	// non-string field untouched instead of setting it to its zero value, e.g.
+	// to distinguish an absent number from 0. Empty query, form, header and
+	// cookie values already leave such fields untouched.
+	NoZeroDefault
+	// By default a "+" in a query string or URL encoded form body is decoded
+	// as a space, like url.ParseQuery does. When the LiteralPlus flag is set,
+	// it's kept as a "+" instead; Body then reads URL encoded form bodies
+	// itself and r.Form isn't populated. A literal plus sign can always be sent
+	// as %2B. The flag has no effect on values that are already decoded, like
+	// those passed to DecodeQuery and DecodeForm.
+	LiteralPlus
+	// When the StrictPath flag is set, a path tag whose param is absent from
+	// the route results in an *UnknownPathParamError instead of binding an
+	// empty value, e.g. to catch a misspelled route wildcard. This requires a
+	// Binder.PathValueFuncOk that can report absent params.
+	StrictPath
+	// When the SemicolonSeparator flag is set, a ";" in a query string
+	// separates parameters like "&", e.g. ?a=1;b=2 as sent by some older
+	// systems. By default pairs containing a semicolon are dropped, like
+	// url.URL.Query does. Like LiteralPlus it has no effect on values passed
+	// to DecodeQuery.
+	SemicolonSeparator
+	// When the RewindBody flag is set, Body reads the whole body into memory
+	// and replaces r.Body with a new reader afterwards, so that it can be read
+	// again, e.g. to verify a webhook signature. MaxBodyBytes still applies.
+	// A compressed body is replaced by its decompressed content, the
+	// Content-Encoding header is then removed and Content-Length updated.
+	RewindBody
+	// When the EmptyBoolTrue flag is set, keys without a value, like verbose
+	// in ?verbose&limit=10, bind bool fields to true, like command line flags.
+	// Aliases and the CaseInsensitive flag are taken into account. Absent keys
+	// still leave the field untouched.
+	EmptyBoolTrue
 )
 
 type Validator interface {
 	ValidateBind() error
 }
 
+// Validatable is implemented by values with a general purpose Validate method.
+// Like ValidateBind, Request calls it after all sources are bound
+// successfully.
+type Validatable interface {
+	Validate() error
+}
+
 var (
-	queryDecoder  = form.NewDecoder()
-	formDecoder   = form.NewDecoder()
-	headerDecoder = form.NewDecoder()
+	// PathValueFunc is used by the package level functions to look up router
+	// path variables. When built with Go 1.22 or later it defaults to
+	// http.Request.PathValue, so that path wildcards of http.ServeMux patterns
+	// are bound without any setup.
+	PathValueFunc func(*http.Request, string) string = defaultPathValueFunc
+
+	// PathValueFuncOk is like PathValueFunc but also reports whether a path
+	// variable is present in the route. It takes precedence over
+	// PathValueFunc when set.
+	PathValueFuncOk func(*http.Request, string) (string, bool)
+
+	// ValidateFunc is called by Request after all sources are bound
+	// successfully. Errors are wrapped in a *ValidationError. To use
+	// go-playground/validator struct tags:
+	//
+	//	bind.ValidateFunc = validator.New().Struct
+	ValidateFunc func(any) error
+
+	// MaxBodyBytes limits the size of request bodies read by Body. When the
+	// limit is exceeded, a *BodyTooLargeError that matches ErrBodyTooLarge is
+	// returned. A value <= 0 disables the limit.
+	MaxBodyBytes int64 = DefaultMaxBodyBytes
+
+	// MaxMultipartMemory is the maximum number of bytes of a multipart body
+	// that is stored in memory by Body, the remainder is stored on disk in
+	// temporary files.
+	MaxMultipartMemory int64 = DefaultMaxMultipartMemory
+
+	// OnError, if set, is called by Request for every binding or validation
+	// error, see Binder.OnError.
+	OnError func(r *http.Request, source string, err error)
+
+	// JSONDecode, if set, replaces encoding/json for JSON bodies, e.g. to use
+	// a faster library, see Binder.JSONDecode.
+	//
+	//	bind.JSONDecode = func(r io.Reader, v any, flags []bind.Flag) error {
+	//		return sonic.ConfigDefault.NewDecoder(r).Decode(v)
+	//	}
+	JSONDecode func(r io.Reader, v any, flags []Flag) error
 
-	queryEncoder  = form.NewEncoder()
-	formEncoder   = form.NewEncoder()
-	headerEncoder = form.NewEncoder()
+	// XMLDecode, if set, replaces encoding/xml for XML bodies, see
+	// Binder.XMLDecode.
+	XMLDecode func(r io.Reader, v any, flags []Flag) error
 
-	PathValueFunc func(*http.Request, string) string
+	// BodyTimeout limits the time Body takes to read the request body. When
+	// it's exceeded, ErrBodyTimeout is returned. A value <= 0 disables the
+	// timeout.
+	BodyTimeout time.Duration
+
+	defaultBinder = New()
+
+	timeType            = reflect.TypeOf(time.Time{})
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	scannerType         = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	nullTimeType        = reflect.TypeOf(sql.NullTime{})
 )
 
-func init() {
-	queryDecoder.SetTagName("query")
-	queryDecoder.SetMode(form.ModeExplicit)
-	formDecoder.SetTagName("form")
-	formDecoder.SetMode(form.ModeExplicit)
-	headerDecoder.SetTagName("header")
-	headerDecoder.SetMode(form.ModeExplicit)
+// binder returns the default Binder configured with the package level
+// PathValueFunc, PathValueFuncOk, ValidateFunc, MaxBodyBytes,
+// MaxMultipartMemory, BodyTimeout, OnError, JSONDecode and XMLDecode.
+func binder() *Binder {
+	b := *defaultBinder
+	b.PathValueFunc = PathValueFunc
+	b.PathValueFuncOk = PathValueFuncOk
+	b.ValidateFunc = ValidateFunc
+	b.MaxBodyBytes = MaxBodyBytes
+	b.MaxMultipartMemory = MaxMultipartMemory
+	b.BodyTimeout = BodyTimeout
+	b.OnError = OnError
+	b.JSONDecode = JSONDecode
+	b.XMLDecode = XMLDecode
+	return &b
+}
+
+// Default returns a clone of the Binder used by the package level functions,
+// configured with the current package level settings. Changes to the clone,
+// like SetQueryTagName or RegisterType, don't affect the package level
+// functions; use the package level variables and functions for that.
+func Default() *Binder {
+	return binder().Clone()
+}
+
+// SetPathValueFunc sets PathValueFunc and returns a function that restores the
+// previous value, e.g. in tests:
+//
+//	defer bind.SetPathValueFunc(fn)()
+//
+// Like PathValueFunc itself this is not safe for concurrent use; give parallel
+// tests their own Binder instead.
+func SetPathValueFunc(fn func(*http.Request, string) string) (restore func()) {
+	prev := PathValueFunc
+	PathValueFunc = fn
+	return func() {
+		PathValueFunc = prev
+	}
+}
 
-	queryEncoder.SetTagName("query")
-	queryEncoder.SetMode(form.ModeExplicit)
-	formEncoder.SetTagName("form")
-	formEncoder.SetMode(form.ModeExplicit)
-	headerEncoder.SetTagName("header")
-	headerEncoder.SetMode(form.ModeExplicit)
+// ResetPathValueFunc resets PathValueFunc to its default.
+func ResetPathValueFunc() {
+	PathValueFunc = defaultPathValueFunc
 }
 
 func EncodeQuery(v any) (url.Values, error) {
-	return queryEncoder.Encode(v)
+	return binder().EncodeQuery(v)
 }
 
 func EncodeForm(v any) (url.Values, error) {
-	return formEncoder.Encode(v)
+	return binder().EncodeForm(v)
 }
 
 func EncodeHeader(v any) (http.Header, error) {
-	vals, err := formEncoder.Encode(v)
-	return http.Header(vals), err
+	return binder().EncodeHeader(v)
+}
+
+// EncodeURL builds a URL from urlTemplate and the path and query tagged values
+// of v. See Binder.EncodeURL.
+func EncodeURL(urlTemplate string, v any) (string, error) {
+	return binder().EncodeURL(urlTemplate, v)
+}
+
+func EncodeCookie(v any) ([]*http.Cookie, error) {
+	return binder().EncodeCookie(v)
+}
+
+// EncodePath returns the path tagged field values of v keyed by parameter
+// name. See Binder.EncodePath.
+func EncodePath(v any) (map[string]string, error) {
+	return binder().EncodePath(v)
+}
+
+func EncodeRequest(method, urlTemplate string, v any) (*http.Request, error) {
+	return binder().EncodeRequest(method, urlTemplate, v)
 }
 
 func DecodeQuery(vals url.Values, v any, flags ...Flag) error {
-	if hasFlag(flags, Vacuum) {
-		vals = vacuum(vals)
-	}
-	return queryDecoder.Decode(v, vals)
+	return binder().DecodeQuery(vals, v, flags...)
 }
 
 func DecodeForm(vals url.Values, v any, flags ...Flag) error {
-	if hasFlag(flags, Vacuum) {
-		vals = vacuum(vals)
-	}
-	return formDecoder.Decode(v, vals)
+	return binder().DecodeForm(vals, v, flags...)
+}
+
+func DecodeValues(vals url.Values, tagName string, v any, flags ...Flag) error {
+	return binder().DecodeValues(vals, tagName, v, flags...)
 }
 
 func DecodeHeader(header http.Header, v any, flags ...Flag) error {
-	vals := url.Values(header)
+	return binder().DecodeHeader(header, v, flags...)
+}
+
+func DecodeCookie(cookies []*http.Cookie, v any, flags ...Flag) error {
+	return binder().DecodeCookie(cookies, v, flags...)
+}
+
+func PathValue(r *http.Request, k string) string {
+	return binder().PathValue(r, k)
+}
+
+func Request(r *http.Request, v any, flags ...Flag) error {
+	return binder().Request(r, v, flags...)
+}
+
+// Bind allocates a new T and binds r to it with Request:
+//
+//	v, err := bind.Bind[ItemRequest](r)
+func Bind[T any](r *http.Request, flags ...Flag) (*T, error) {
+	v := new(T)
+	if err := Request(r, v, flags...); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func Query(r *http.Request, v any, flags ...Flag) error {
+	return binder().Query(r, v, flags...)
+}
+
+func QueryLeftover(r *http.Request, v any, flags ...Flag) (url.Values, error) {
+	return binder().QueryLeftover(r, v, flags...)
+}
+
+func Body(r *http.Request, v any, flags ...Flag) error {
+	return binder().Body(r, v, flags...)
+}
+
+func BodyWithPresence(r *http.Request, v any, flags ...Flag) (FieldSet, error) {
+	return binder().BodyWithPresence(r, v, flags...)
+}
+
+func BodyStream(r *http.Request, fn func(decode func(any) error) error, flags ...Flag) error {
+	return binder().BodyStream(r, fn, flags...)
+}
+
+func DecodeBody(r io.Reader, contentType string, v any, flags ...Flag) error {
+	return binder().DecodeBody(r, contentType, v, flags...)
+}
+
+func Context(r *http.Request, v any, flags ...Flag) error {
+	return binder().Context(r, v, flags...)
+}
+
+func Header(r *http.Request, v any, flags ...Flag) error {
+	return binder().Header(r, v, flags...)
+}
+
+func Cookie(r *http.Request, v any, flags ...Flag) error {
+	return binder().Cookie(r, v, flags...)
+}
+
+func Path(r *http.Request, v any, flags ...Flag) error {
+	return binder().Path(r, v, flags...)
+}
+
+// RegisterType registers a conversion function for type t with the default
+// Binder. See Binder.RegisterType.
+func RegisterType(t reflect.Type, fn TypeFunc) {
+	defaultBinder.RegisterType(t, fn)
+}
+
+// RegisterInterface registers a factory function for interface type t with the
+// default Binder. See Binder.RegisterInterface.
+func RegisterInterface(t reflect.Type, fn InterfaceFunc) {
+	defaultBinder.RegisterInterface(t, fn)
+}
+
+// RegisterContentEncoding registers a content encoding with the default
+// Binder. See Binder.RegisterContentEncoding.
+func RegisterContentEncoding(name string, fn ContentEncodingFunc) {
+	defaultBinder.RegisterContentEncoding(name, fn)
+}
+
+// RegisterBodyDecoder registers a body decoder for contentType with the default
+// Binder. See Binder.RegisterBodyDecoder.
+func RegisterBodyDecoder(contentType string, fn BodyDecoderFunc) {
+	defaultBinder.RegisterBodyDecoder(contentType, fn)
+}
+
+// With returns a clone of the default Binder configured with opts. See
+// Binder.With.
+func With(opts ...Option) *Binder {
+	return binder().With(opts...)
+}
+
+// Sources returns the sources v has tagged fields for with the default Binder.
+// See Binder.Sources.
+func Sources(v any) Source {
+	return binder().Sources(v)
+}
+
+// Describe returns a table of the fields of v with the source, name and type
+// they are bound from.
+func Describe(v any) string {
+	return binder().Describe(v)
+}
+
+// Check returns an error for every field of v with a binding tag that has an
+// unsupported type.
+func Check(v any) error {
+	return binder().Check(v)
+}
+
+// RegisterContextKey maps a ctx tag name to a context key with the default
+// Binder. See Binder.RegisterContextKey.
+func RegisterContextKey(name string, key any) {
+	defaultBinder.RegisterContextKey(name, key)
+}
+
+// parseQuery is like url.ParseQuery but keeps "+" as is if literalPlus is
+// true.
+func parseQuery(query string, literalPlus bool) (url.Values, error) {
+	if literalPlus {
+		query = strings.ReplaceAll(query, "+", "%2B")
+	}
+	return url.ParseQuery(query)
+}
+
+// queryValues returns the parsed query string of r, ignoring malformed
+// pairs like url.URL.Query does.
+func queryValues(r *http.Request, flags []Flag) url.Values {
+	literalPlus := hasFlag(flags, LiteralPlus)
+	semicolons := hasFlag(flags, SemicolonSeparator)
+	if !literalPlus && !semicolons {
+		return r.URL.Query()
+	}
+	query := r.URL.RawQuery
+	if semicolons {
+		query = strings.ReplaceAll(query, ";", "&")
+	}
+	vals, _ := parseQuery(query, literalPlus)
+	return vals
+}
+
+// expandPath replaces the {param} placeholders in tmpl with the escaped
+// parameter values. Like in http.ServeMux patterns, the slashes of a {param...}
+// remainder are kept and {$} is dropped. It returns an error for a placeholder
+// without a value, e.g. of a nil pointer field, instead of leaving it in the
+// path.
+func expandPath(tmpl string, params map[string]string) (string, error) {
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(tmpl, '{')
+		if i == -1 {
+			break
+		}
+		j := strings.IndexByte(tmpl[i:], '}')
+		if j == -1 {
+			break
+		}
+		b.WriteString(tmpl[:i])
+		name := tmpl[i+1 : i+j]
+		tmpl = tmpl[i+j+1:]
+		if name == "$" {
+			continue
+		}
+		remainder := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+		val, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("bind: no value for path param %q", name)
+		}
+		if remainder {
+			segments := strings.Split(val, "/")
+			for k, segment := range segments {
+				segments[k] = url.PathEscape(segment)
+			}
+			b.WriteString(strings.Join(segments, "/"))
+		} else {
+			b.WriteString(url.PathEscape(val))
+		}
+	}
+	b.WriteString(tmpl)
+	return b.String(), nil
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// hasBody reports whether r has a non empty body. If the body length is
+// unknown, e.g. with chunked transfer encoding, the first byte is read and
+// r.Body is replaced with a reader that still returns it.
+func hasBody(r *http.Request) (bool, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false, nil
+	}
+	if r.ContentLength > 0 {
+		return true, nil
+	}
+	var buf [1]byte
+	if _, err := io.ReadFull(r.Body, buf[:]); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(buf[:]), r.Body), r.Body}
+	return true, nil
+}
+
+// defaultEncodings are the content encodings supported by a new Binder.
+var defaultEncodings = map[string]ContentEncodingFunc{
+	"gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"x-gzip": func(r io.Reader) (io.ReadCloser, error) {
+		return gzip.NewReader(r)
+	},
+	"deflate": func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	},
+}
+
+// decompress wraps body in readers for the given Content-Encoding. Multiple
+// encodings are undone in reverse order, identity is skipped. The returned
+// function closes the readers but not body.
+func (b *Binder) decompress(body io.Reader, contentEncoding string) (io.Reader, func(), error) {
+	var closers []io.Closer
+	closeAll := func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i].Close()
+		}
+	}
+
+	encodings := strings.Split(contentEncoding, ",")
+	for i := len(encodings) - 1; i >= 0; i-- {
+		enc := strings.ToLower(strings.TrimSpace(encodings[i]))
+		if enc == "identity" {
+			continue
+		}
+		fn, ok := b.encodings[enc]
+		if !ok {
+			closeAll()
+			return nil, nil, &UnsupportedContentEncodingError{Encoding: enc}
+		}
+		rc, err := fn(body)
+		if err != nil {
+			closeAll()
+			return nil, nil, err
+		}
+		closers = append(closers, rc)
+		body = rc
+	}
+	return body, closeAll, nil
+}
+
+// decodeValues cleans vals if requested, fills in default values, checks
+// required values, converts time values with a custom layout and decodes the
+// result into v. Defaults are applied after
+// Vacuum, so blank values also receive their default.
+func decodeValues(dec *valuesDecoder, vals url.Values, v any, flags []Flag) error {
+	// present bool keys are set before Vacuum drops their empty values
+	if hasFlag(flags, EmptyBoolTrue) {
+		vals = withPresentBools(vals, dec.fields(v), hasFlag(flags, CaseInsensitive))
+	}
 	if hasFlag(flags, Vacuum) {
 		vals = vacuum(vals)
+	} else if hasFlag(flags, Trim) {
+		vals = trim(vals)
+	}
+
+	// maps receive the values as is
+	switch m := v.(type) {
+	case *map[string][]string:
+		if m == nil {
+			return &form.InvalidDecoderError{Type: reflect.TypeOf(v)}
+		}
+		if *m == nil {
+			*m = make(map[string][]string, len(vals))
+		}
+		for k, vs := range vals {
+			(*m)[k] = append([]string(nil), vs...)
+		}
+		return nil
+	case *url.Values:
+		if m == nil {
+			return &form.InvalidDecoderError{Type: reflect.TypeOf(v)}
+		}
+		return decodeValues(dec, vals, (*map[string][]string)(m), nil)
+	case *map[string]string:
+		if m == nil {
+			return &form.InvalidDecoderError{Type: reflect.TypeOf(v)}
+		}
+		if *m == nil {
+			*m = make(map[string]string, len(vals))
+		}
+		for k, vs := range vals {
+			if len(vs) > 0 {
+				(*m)[k] = vs[0]
+			}
+		}
+		return nil
+	}
+
+	// only structs and maps can hold named values, catch e.g. slices here
+	// instead of returning a confusing form error
+	if t := reflect.TypeOf(v); t != nil && t.Kind() == reflect.Ptr {
+		et := t.Elem()
+		for et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		if et.Kind() != reflect.Struct && et.Kind() != reflect.Map {
+			return &UnsupportedTypeError{Type: t}
+		}
+	}
+
+	vals = withoutEmptyBrackets(vals)
+
+	fields := dec.fields(v)
+	if err := checkValueFields(reflect.TypeOf(v), fields, dec.tagName); err != nil {
+		return err
+	}
+	vals = withAliases(vals, fields)
+	vals, err := withIndexedKeys(vals, fields, dec.maxArraySize)
+	if err != nil {
+		return err
+	}
+	if hasFlag(flags, CaseInsensitive) {
+		vals = withCanonicalKeys(vals, fields)
+	}
+	vals = withDefaults(vals, fields)
+	// split after the defaults are added so that a default like "1,2" is
+	// split too
+	vals = withDelimValues(vals, fields)
+	if err := checkRequired(vals, fields); err != nil {
+		return err
+	}
+	if err := checkArrayLen(vals, fields); err != nil {
+		return err
+	}
+	vals, err = withTimeLayouts(vals, fields, dec.timeFormat)
+	if err != nil {
+		return err
+	}
+	vals, err = withIntBases(vals, fields)
+	if err != nil {
+		return err
+	}
+	if err := checkRanges(vals, fields); err != nil {
+		return err
+	}
+	vals, unmarshalerVals := withoutUnmarshalers(vals, fields)
+	vals, fallbackVals := withoutFallbacks(vals, fields)
+	if err := dec.decoder.Decode(v, vals); err != nil {
+		return err
+	}
+	if len(fallbackVals) > 0 {
+		if err := dec.fallbackDecoder.Decode(v, fallbackVals); err != nil {
+			return err
+		}
 	}
-	return headerDecoder.Decode(v, vals)
+	return setUnmarshalers(v, fields, unmarshalerVals)
 }
 
-func PathValue(r *http.Request, k string) string {
-	if PathValueFunc != nil {
-		return PathValueFunc(r, k)
+// withoutFallbacks moves the values of fallback fields, including indexed
+// keys like tags[0], to a separate set for the fallback decoder.
+func withoutFallbacks(vals url.Values, fields []valueField) (url.Values, url.Values) {
+	var keys []string
+	for _, f := range fields {
+		if f.fallback {
+			keys = append(keys, f.key)
+		}
+	}
+	if len(keys) == 0 {
+		return vals, nil
+	}
+
+	newVals := make(url.Values, len(vals))
+	fallbackVals := make(url.Values)
+	for k, vs := range vals {
+		if isFallbackKey(k, keys) {
+			fallbackVals[k] = vs
+		} else {
+			newVals[k] = vs
+		}
 	}
-	return ""
+	return newVals, fallbackVals
 }
 
-func Request(r *http.Request, v any, flags ...Flag) error {
-	if PathValueFunc != nil {
-		if err := Path(r, v, flags...); err != nil {
-			return err
+func isFallbackKey(k string, keys []string) bool {
+	for _, key := range keys {
+		if k == key || (strings.HasPrefix(k, key) && k[len(key)] == '[') {
+			return true
+		}
+	}
+	return false
+}
+
+func vacuum(values url.Values) url.Values {
+	newValues := make(url.Values)
+	for key, vals := range values {
+		var newVals []string
+		for _, val := range vals {
+			val = strings.TrimSpace(val)
+			if val != "" {
+				newVals = append(newVals, val)
+			}
+		}
+		if len(newVals) > 0 {
+			newValues[key] = newVals
+		}
+	}
+	return newValues
+}
+
+// valueField describes a tagged struct field decoded from url.Values.
+type valueField struct {
+	index      []int
+	field      string
+	key        string
+	def        string
+	hasDefault bool
+	required   bool
+	timeLayout string
+	isTime     bool
+	raw        bool
+	// aliases are the keys of alias tag options, their values are merged
+	// into key by withAliases.
+	aliases []string
+	// unsupported is set for types that can't be bound from strings, see
+	// isUnsupportedType
+	unsupported bool
+	isBool      bool
+	// fallback is set for fields found by their fallback tag, their values
+	// are decoded by the fallback decoder.
+	fallback bool
+	// unmarshaler is set for json.Unmarshaler types that aren't a
+	// TextUnmarshaler; these are set by setUnmarshalers.
+	unmarshaler bool
+	// json is set for fields with a json tag option, their values are
+	// decoded with json.Unmarshal by setUnmarshalers.
+	json bool
+	// enum is set for integer types that implement encoding.TextUnmarshaler,
+	// see unmarshalText.
+	enum bool
+	// text is set for []byte types that implement encoding.TextUnmarshaler,
+	// like net.IP.
+	text bool
+	// scanner is set for structs that implement sql.Scanner, like
+	// sql.NullString, see scanValue.
+	scanner bool
+	slice   bool
+	// arrayLen is the length of array fields
+	arrayLen int
+	// intBase is the base of integer fields, see intBase
+	intBase int
+	// delim is the delim tag of slice and array fields
+	delim string
+	// numType is the integer or float (element) type of numeric fields
+	numType reflect.Type
+	// bytesEncoding is set for []byte fields, which are decoded from base64
+	// or hex by setUnmarshalers.
+	bytesEncoding string
+}
+
+// namespace determines how the keys of nested struct fields are formed.
+type namespace struct {
+	prefix string
+	suffix string
+}
+
+var defaultNamespace = namespace{prefix: "."}
+
+// key returns the key of field name nested in parent.
+func (ns namespace) key(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + ns.prefix + name + ns.suffix
+}
+
+type valueFieldsCacheKey struct {
+	typ         reflect.Type
+	tagName     string
+	fallbackTag string
+	ns          namespace
+}
+
+var valueFieldsCache sync.Map // map[valueFieldsCacheKey][]valueField
+
+func cachedValueFields(t reflect.Type, tagName, fallbackTag string, ns namespace) []valueField {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	key := valueFieldsCacheKey{t, tagName, fallbackTag, ns}
+	if fields, ok := valueFieldsCache.Load(key); ok {
+		return fields.([]valueField)
+	}
+	fields, _ := valueFieldsCache.LoadOrStore(key, valueFields(t, tagName, fallbackTag, ns, nil, "", "", nil))
+	return fields.([]valueField)
+}
+
+// valueFields returns the fields of t tagged with tagName. If fallbackTag
+// isn't empty, fields without tagName are looked up by their fallbackTag name
+// instead, as are the fields nested in them. parents are the struct types
+// being walked; fields of a recursive type, e.g. a Parent *Cat field of Cat,
+// are skipped and left to the form decoder.
+func valueFields(t reflect.Type, tagName, fallbackTag string, ns namespace, index []int, fieldPrefix, parentKey string, parents []reflect.Type) []valueField {
+	var fields []valueField
+	parents = append(parents[:len(parents):len(parents)], t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		// files are set by decodeFiles, see fileFields
+		if field.Type == fileHeaderType || field.Type == fileHeaderSliceType {
+			continue
+		}
+
+		fieldTag, nestedFallbackTag := tagName, fallbackTag
+		name, opts := parseTag(field.Tag.Get(tagName))
+		if name == "" && fallbackTag != "" && !hasOption(opts, "raw") {
+			fieldTag, nestedFallbackTag = fallbackTag, ""
+			name, opts = parseTag(field.Tag.Get(fallbackTag))
+		}
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			if hasOption(opts, "raw") && field.Type.Kind() == reflect.String {
+				fields = append(fields, valueField{index: fieldIndex, field: fieldPrefix + field.Name, raw: true})
+			}
+			continue
+		}
+		fallback := fieldTag != tagName
+
+		ft := field.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		unmarshaler := isJSONUnmarshaler(ft)
+		asJSON := hasOption(opts, "json")
+		scanner := isScannerType(ft)
+		if ft.Kind() == reflect.Struct && ft != timeType && !unmarshaler && !asJSON && !scanner {
+			if isParentType(ft, parents) {
+				continue
+			}
+			var nested []valueField
+			if field.Anonymous {
+				// like the form decoder, the fields of a tagged embedded
+				// struct are bound by their key prefixed with its name, e.g.
+				// pg.sort, as well as by their own key, which is added as an
+				// alias
+				nested = valueFields(ft, fieldTag, nestedFallbackTag, ns, fieldIndex, fieldPrefix, ns.key(parentKey, name), parents)
+				flat := valueFields(ft, fieldTag, nestedFallbackTag, ns, fieldIndex, fieldPrefix, parentKey, parents)
+				for i := range nested {
+					if flat[i].key != "" {
+						nested[i].aliases = append(nested[i].aliases, flat[i].key)
+						nested[i].aliases = append(nested[i].aliases, flat[i].aliases...)
+					}
+				}
+			} else {
+				nested = valueFields(ft, fieldTag, nestedFallbackTag, ns, fieldIndex, fieldPrefix+field.Name+".", ns.key(parentKey, name), parents)
+			}
+			if fallback {
+				for i := range nested {
+					nested[i].fallback = true
+				}
+			}
+			// a struct without tagged fields is treated as a single value,
+			// it might have a registered TypeFunc
+			if len(nested) > 0 || field.Anonymous {
+				fields = append(fields, nested...)
+				continue
+			}
+		}
+
+		def, hasDefault := field.Tag.Lookup("default")
+		required := isRequired(field.Tag)
+		var timeLayout string
+		// sql.NullTime values are normalized like time.Time values
+		isTime := isTimeType(ft) || ft == nullTimeType
+		if isTime {
+			timeLayout = field.Tag.Get("format")
+		}
+		f := valueField{
+			index:       fieldIndex,
+			field:       fieldPrefix + field.Name,
+			key:         ns.key(parentKey, name),
+			def:         def,
+			hasDefault:  hasDefault,
+			required:    required,
+			timeLayout:  timeLayout,
+			isTime:      isTime,
+			fallback:    fallback,
+			unsupported: isUnsupportedType(field.Type),
+			isBool:      ft.Kind() == reflect.Bool,
+			aliases:     aliases(opts, ns, parentKey),
+
+			unmarshaler: unmarshaler,
+			json:        asJSON,
+			enum:        isEnumType(ft),
+			text:        isBytesType(ft) && reflect.PtrTo(ft).Implements(textUnmarshalerType),
+			scanner:     scanner,
+			intBase:     intBase(ft, field.Tag),
+			numType:     numericType(ft),
+		}
+		if !asJSON {
+			// []byte types that decode themselves are single values
+			f.slice = ft.Kind() == reflect.Slice && !f.unmarshaler && !f.text
+			f.bytesEncoding = bytesEncoding(ft, field.Tag)
+			f.arrayLen = arrayLen(ft)
+			if (f.slice || f.arrayLen > 0) && f.bytesEncoding == "" {
+				f.delim = field.Tag.Get("delim")
+			}
+		}
+		fields = append(fields, f)
+	}
+
+	return fields
+}
+
+// isParentType reports whether t is one of parents.
+func isParentType(t reflect.Type, parents []reflect.Type) bool {
+	for _, p := range parents {
+		if p == t {
+			return true
+		}
+	}
+	return false
+}
+
+// isUnsupportedType reports whether t, or the element type of t, is a
+// channel, func or unsafe pointer, which can't be bound from strings.
+func isUnsupportedType(t reflect.Type) bool {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Chan, reflect.Func, reflect.UnsafePointer:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// checkValueFields returns an *UnsupportedFieldError for the first
+// unsupported field of type t.
+func checkValueFields(t reflect.Type, fields []valueField, tagName string) error {
+	for _, f := range fields {
+		if f.unsupported {
+			return &UnsupportedFieldError{Field: f.field, Tag: tagName, Type: typeByIndex(t, f.index)}
+		}
+	}
+	return nil
+}
+
+// checkTagFields is like checkValueFields for path fields.
+func checkTagFields(t reflect.Type, fields []tagField, tagName string) error {
+	for _, f := range fields {
+		if f.unsupported {
+			return &UnsupportedFieldError{Field: f.field, Tag: tagName, Type: typeByIndex(t, f.index)}
+		}
+	}
+	return nil
+}
+
+// aliases returns the keys of the alias options in opts, e.g.
+// form:"email,alias=e-mail".
+func aliases(opts []string, ns namespace, parentKey string) []string {
+	var keys []string
+	for _, o := range opts {
+		if strings.HasPrefix(o, "alias=") && len(o) > len("alias=") {
+			keys = append(keys, ns.key(parentKey, o[len("alias="):]))
+		}
+	}
+	return keys
+}
+
+// withAliases returns a copy of values with the values of alias keys, including
+// indexed keys like e-mail[0], merged into the key of their field.
+func withAliases(values url.Values, fields []valueField) url.Values {
+	aliases := make(map[string]string)
+	for _, f := range fields {
+		for _, alias := range f.aliases {
+			aliases[alias] = f.key
+		}
+	}
+	if len(aliases) == 0 {
+		return values
+	}
+
+	newValues := make(url.Values, len(values))
+	for k, vals := range values {
+		newKey := k
+		if i := matchKey(k, func(base string) bool {
+			_, ok := aliases[base]
+			return ok
+		}); i != -1 {
+			newKey = aliases[k[:i]] + k[i:]
+		}
+		newValues[newKey] = append(newValues[newKey], vals...)
+	}
+	return newValues
+}
+
+// parseTag splits a struct tag value in a name and options.
+func parseTag(tag string) (string, []string) {
+	name, opts, _ := strings.Cut(tag, ",")
+	if opts == "" {
+		return name, nil
+	}
+	return name, strings.Split(opts, ",")
+}
+
+func hasOption(opts []string, opt string) bool {
+	for _, o := range opts {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
+
+// setRaw sets all raw fields of v to raw.
+func setRaw(v any, fields []valueField, raw string) {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return
+	}
+	for _, f := range fields {
+		if !f.raw {
+			continue
+		}
+		if fieldVal, ok := fieldByIndexAlloc(val.Elem(), f.index); ok {
+			fieldVal.SetString(raw)
+		}
+	}
+}
+
+// bytesEncoding returns the encoding of []byte type t, base64 unless
+// overridden with an encoding tag. It returns "" for other types and for
+// []byte types that decode themselves, like json.RawMessage and net.IP.
+func bytesEncoding(t reflect.Type, tag reflect.StructTag) string {
+	if !isBytesType(t) {
+		return ""
+	}
+	if pt := reflect.PtrTo(t); pt.Implements(jsonUnmarshalerType) || pt.Implements(textUnmarshalerType) {
+		return ""
+	}
+	if enc := tag.Get("encoding"); enc != "" {
+		return enc
+	}
+	return "base64"
+}
+
+func isBytesType(t reflect.Type) bool {
+	return t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// intBase returns the base of integer values of type t from the base tag, e.g.
+// base:"16" or base:"0" to detect the base from a 0x, 0o or 0b prefix. It
+// returns 10 without a valid base tag or for non integer types.
+func intBase(t reflect.Type, tag reflect.StructTag) int {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if !isIntKind(t.Kind()) {
+		return 10
+	}
+	base, err := strconv.Atoi(tag.Get("base"))
+	if err != nil || base == 1 || base < 0 || base > 36 {
+		return 10
+	}
+	return base
+}
+
+// withIntBases returns a copy of values with the values of integer fields
+// with a base tag converted to base 10 for the form decoder. Fields set by
+// setUnmarshalers are skipped, unmarshalText honors the base of enums itself.
+// values is returned unchanged if there are no such fields.
+func withIntBases(values url.Values, fields []valueField) (url.Values, error) {
+	var newValues url.Values
+	var errs form.DecodeErrors
+	for _, f := range fields {
+		if f.raw || f.intBase == 10 || f.setByUnmarshalers() || len(values[f.key]) == 0 {
+			continue
+		}
+		if newValues == nil {
+			newValues = make(url.Values, len(values))
+			for k, vals := range values {
+				newValues[k] = vals
+			}
+		}
+		vals := make([]string, len(values[f.key]))
+		for i, val := range values[f.key] {
+			if val == "" {
+				continue
+			}
+			var err error
+			if strings.HasPrefix(val, "-") {
+				var n int64
+				n, err = strconv.ParseInt(val, f.intBase, 64)
+				vals[i] = strconv.FormatInt(n, 10)
+			} else {
+				var n uint64
+				n, err = strconv.ParseUint(strings.TrimPrefix(val, "+"), f.intBase, 64)
+				vals[i] = strconv.FormatUint(n, 10)
+			}
+			// the range is checked here, so that the error holds the value
+			// as sent instead of the converted one
+			if err == nil && f.numType != nil {
+				err = parseNumber(vals[i], f.numType)
+			}
+			if errors.Is(err, strconv.ErrRange) && f.numType != nil {
+				err = newRangeError(val, f.numType)
+			}
+			if err != nil {
+				if errs == nil {
+					errs = make(form.DecodeErrors)
+				}
+				errs[f.key] = err
+				break
+			}
+		}
+		newValues[f.key] = vals
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	if newValues == nil {
+		return values, nil
+	}
+	return newValues, nil
+}
+
+// setBytesField decodes val with the base64 (standard encoding, like
+// encoding/json) or hex encoding.
+func setBytesField(val, encoding string, field reflect.Value) error {
+	var b []byte
+	var err error
+	switch encoding {
+	case "base64":
+		b, err = base64.StdEncoding.DecodeString(val)
+	case "hex":
+		b, err = hex.DecodeString(val)
+	default:
+		err = fmt.Errorf("bind: unknown encoding %q", encoding)
+	}
+	if err == nil {
+		field.SetBytes(b)
+	}
+	return err
+}
+
+func isStringType(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.String
+}
+
+// isJSONUnmarshaler reports whether *t implements json.Unmarshaler but not
+// encoding.TextUnmarshaler.
+func isJSONUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(jsonUnmarshalerType) && !pt.Implements(textUnmarshalerType)
+}
+
+// isEnumType reports whether t is an integer type and *t implements
+// encoding.TextUnmarshaler.
+func isEnumType(t reflect.Type) bool {
+	return isIntKind(t.Kind()) && reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+func isIntKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+// unmarshalText sets field with its UnmarshalText method. If that fails for
+// an integer type, e.g. an enum that accepts names, val is parsed as an integer
+// instead so that both "active" and "1" are accepted. The error of
+// UnmarshalText is returned if both fail.
+func unmarshalText(val string, base int, field reflect.Value) error {
+	err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(val))
+	if err == nil || !isIntKind(field.Kind()) || val == "" {
+		return err
+	}
+	if field.CanInt() {
+		if n, intErr := strconv.ParseInt(val, base, field.Type().Bits()); intErr == nil {
+			field.SetInt(n)
+			return nil
+		}
+	} else if n, uintErr := strconv.ParseUint(val, base, field.Type().Bits()); uintErr == nil {
+		field.SetUint(n)
+		return nil
+	}
+	return err
+}
+
+// isScannerType reports whether t is a struct and *t implements sql.Scanner,
+// like the sql.Null types.
+func isScannerType(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && reflect.PtrTo(t).Implements(scannerType)
+}
+
+// scanValue sets field with its Scan method, which marks sql.Null types as
+// valid. sql.NullTime values are parsed with layout, time.RFC3339 by default.
+func scanValue(val, layout string, field reflect.Value) error {
+	var src any = val
+	if field.Type() == nullTimeType {
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		t, err := parseTime(layout, val)
+		if err != nil {
+			return err
+		}
+		src = t
+	}
+	return field.Addr().Interface().(sql.Scanner).Scan(src)
+}
+
+// isTimeType reports whether t is time.Time or a slice of (pointers to)
+// time.Time.
+func isTimeType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == timeType
+}
+
+func isRequired(tag reflect.StructTag) bool {
+	required, _ := strconv.ParseBool(tag.Get("required"))
+	return required
+}
+
+func isBlank(vals []string) bool {
+	return len(vals) == 0 || (len(vals) == 1 && vals[0] == "")
+}
+
+// withCanonicalKeys returns a copy of values with keys that match a field key
+// case insensitively renamed to the field key. Values of keys that map to the
+// same field key are merged.
+func withCanonicalKeys(values url.Values, fields []valueField) url.Values {
+	keys := make(map[string]string, len(fields))
+	for _, f := range fields {
+		if f.key != "" {
+			keys[strings.ToLower(f.key)] = f.key
+		}
+		for _, alias := range f.aliases {
+			keys[strings.ToLower(alias)] = f.key
+		}
+	}
+
+	newValues := make(url.Values, len(values))
+	for k, vals := range values {
+		newKey := k
+		if i := matchKey(k, func(base string) bool {
+			_, ok := keys[strings.ToLower(base)]
+			return ok
+		}); i != -1 {
+			newKey = keys[strings.ToLower(k[:i])] + k[i:]
+		}
+		newValues[newKey] = append(newValues[newKey], vals...)
+	}
+	return newValues
+}
+
+// matchKey returns the length of the longest part of key k that matches a
+// field key, either k itself or k up to one of its '[' characters. It returns
+// -1 if nothing matches.
+func matchKey(k string, match func(string) bool) int {
+	for i := len(k); i != -1; i = strings.LastIndexByte(k[:i], '[') {
+		if match(k[:i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// withoutEmptyBrackets returns a copy of values with a trailing [] removed
+// from the keys, e.g. roles[]=a becomes roles=a. values is returned unchanged
+// if no key ends in [].
+func withoutEmptyBrackets(values url.Values) url.Values {
+	found := false
+	for k := range values {
+		if strings.HasSuffix(k, "[]") {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return values
+	}
+
+	newValues := make(url.Values, len(values))
+	for k, vals := range values {
+		k = strings.TrimSuffix(k, "[]")
+		newValues[k] = append(newValues[k], vals...)
+	}
+	return newValues
+}
+
+// withIndexedKeys returns a copy of values with indexed keys of slice and
+// array fields merged in index order, e.g. tags[1]=b&tags[0]=a becomes
+// tags=a&tags=b. Gaps in sparse indices are filled with empty values, which
+// bind as zero values. Values of the plain key come before indexed ones.
+// Indices of maxArraySize or more return an error, which protects against
+// allocating huge slices for sparse indices like tags[1000000]. values is
+// returned unchanged if there are no indexed keys.
+func withIndexedKeys(values url.Values, fields []valueField, maxArraySize int) (url.Values, error) {
+	var indexed map[string]map[int]string
+	for k, vals := range values {
+		name, idx, ok := parseIndexedKey(k)
+		if !ok || len(vals) == 0 || !isListField(fields, name) {
+			continue
+		}
+		if idx >= maxArraySize {
+			return nil, form.DecodeErrors{k: fmt.Errorf("bind: index %d exceeds maximum array size of %d", idx, maxArraySize)}
+		}
+		if indexed == nil {
+			indexed = make(map[string]map[int]string)
+		}
+		if indexed[name] == nil {
+			indexed[name] = make(map[int]string)
+		}
+		indexed[name][idx] = vals[0]
+	}
+	if indexed == nil {
+		return values, nil
+	}
+
+	newValues := make(url.Values, len(values))
+	for k, vals := range values {
+		if name, _, ok := parseIndexedKey(k); ok && indexed[name] != nil {
+			continue
+		}
+		newValues[k] = vals
+	}
+	for name, idxVals := range indexed {
+		n := 0
+		for idx := range idxVals {
+			if idx >= n {
+				n = idx + 1
+			}
+		}
+		vals := make([]string, n)
+		for idx, v := range idxVals {
+			vals[idx] = v
+		}
+		newValues[name] = append(append([]string(nil), newValues[name]...), vals...)
+	}
+	return newValues, nil
+}
+
+// parseIndexedKey splits a key like tags[1] in its name and index.
+func parseIndexedKey(k string) (string, int, bool) {
+	if !strings.HasSuffix(k, "]") {
+		return "", 0, false
+	}
+	i := strings.LastIndexByte(k, '[')
+	if i <= 0 {
+		return "", 0, false
+	}
+	idx, err := strconv.Atoi(k[i+1 : len(k)-1])
+	if err != nil || idx < 0 {
+		return "", 0, false
+	}
+	return k[:i], idx, true
+}
+
+func isListField(fields []valueField, key string) bool {
+	for _, f := range fields {
+		if f.key == key {
+			return (f.slice || f.arrayLen > 0) && f.bytesEncoding == ""
+		}
+	}
+	return false
+}
+
+// unboundValues returns the values whose keys don't match the key or an alias
+// of any of fields.
+func unboundValues(values url.Values, fields []valueField, caseInsensitive bool) url.Values {
+	keys := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		for _, k := range append([]string{f.key}, f.aliases...) {
+			if k == "" {
+				continue
+			}
+			if caseInsensitive {
+				k = strings.ToLower(k)
+			}
+			keys[k] = struct{}{}
+		}
+	}
+
+	unbound := url.Values{}
+	for k, vals := range values {
+		i := matchKey(strings.TrimSuffix(k, "[]"), func(base string) bool {
+			if caseInsensitive {
+				base = strings.ToLower(base)
+			}
+			_, ok := keys[base]
+			return ok
+		})
+		if i == -1 {
+			unbound[k] = vals
+		}
+	}
+	return unbound
+}
+
+// withDefaults returns a copy of values with the default tag values of
+// fields added for keys that are absent or empty. values is returned
+// unchanged if there is nothing to add.
+func withDefaults(values url.Values, fields []valueField) url.Values {
+	var newValues url.Values
+	for _, f := range fields {
+		if !f.hasDefault || !isBlank(values[f.key]) {
+			continue
+		}
+		if newValues == nil {
+			newValues = make(url.Values, len(values))
+			for k, vals := range values {
+				newValues[k] = vals
+			}
+		}
+		newValues[f.key] = []string{f.def}
+	}
+	if newValues == nil {
+		return values
+	}
+	return newValues
+}
+
+// withTimeLayouts returns a copy of values with the values of time fields that
+// have a format tag, or any time field if defaultLayout is set, converted to
+// RFC3339, the layout the decoders understand.
+func withTimeLayouts(values url.Values, fields []valueField, defaultLayout string) (url.Values, error) {
+	var newValues url.Values
+	var errs form.DecodeErrors
+	for _, f := range fields {
+		layout := f.timeLayout
+		if layout == "" && f.isTime {
+			layout = defaultLayout
+		}
+		if layout == "" || len(values[f.key]) == 0 {
+			continue
+		}
+		if newValues == nil {
+			newValues = make(url.Values, len(values))
+			for k, vals := range values {
+				newValues[k] = vals
+			}
+		}
+		vals := make([]string, len(values[f.key]))
+		for i, val := range values[f.key] {
+			if val == "" {
+				continue
+			}
+			t, err := parseTime(layout, val)
+			if err != nil {
+				if errs == nil {
+					errs = make(form.DecodeErrors)
+				}
+				errs[f.key] = err
+				break
+			}
+			vals[i] = t.Format(time.RFC3339Nano)
+		}
+		newValues[f.key] = vals
+	}
+	if errs != nil {
+		return nil, errs
+	}
+	if newValues == nil {
+		return values, nil
+	}
+	return newValues, nil
+}
+
+// withHeaderKeys returns a copy of header values with the values of
+// canonical header keys also added under the field keys and aliases that
+// aren't canonical, e.g. X-Api-Key under x-api-key. values is returned
+// unchanged if all field keys are canonical.
+func withHeaderKeys(values url.Values, fields []valueField) url.Values {
+	var newValues url.Values
+	for _, f := range fields {
+		for _, name := range append([]string{f.key}, f.aliases...) {
+			key := http.CanonicalHeaderKey(name)
+			if key == name || len(values[key]) == 0 || len(values[name]) > 0 {
+				continue
+			}
+			if newValues == nil {
+				newValues = make(url.Values, len(values))
+				for k, vals := range values {
+					newValues[k] = vals
+				}
+			}
+			newValues[name] = values[key]
+		}
+	}
+	if newValues == nil {
+		return values
+	}
+	return newValues
+}
+
+// withPresentBools returns a copy of values with the empty values of bool
+// fields replaced by true, see the EmptyBoolTrue flag.
+func withPresentBools(values url.Values, fields []valueField, caseInsensitive bool) url.Values {
+	var newValues url.Values
+	for k, vals := range values {
+		if !isBoolKey(fields, k, caseInsensitive) || !hasEmptyValue(vals) {
+			continue
+		}
+		if newValues == nil {
+			newValues = make(url.Values, len(values))
+			for k, vals := range values {
+				newValues[k] = vals
+			}
+		}
+		newVals := make([]string, len(vals))
+		for i, val := range vals {
+			if val == "" {
+				val = "true"
+			}
+			newVals[i] = val
+		}
+		newValues[k] = newVals
+	}
+	if newValues == nil {
+		return values
+	}
+	return newValues
+}
+
+func hasEmptyValue(vals []string) bool {
+	for _, val := range vals {
+		if val == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// isBoolKey reports whether key is the key or an alias of a bool field. Like
+// in decodeValues, a trailing [] is ignored.
+func isBoolKey(fields []valueField, key string, caseInsensitive bool) bool {
+	key = strings.TrimSuffix(key, "[]")
+	for _, f := range fields {
+		if !f.isBool {
+			continue
+		}
+		for _, name := range append([]string{f.key}, f.aliases...) {
+			if name == key || caseInsensitive && strings.EqualFold(name, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// withSplitValues returns a copy of values with the values of slice fields,
+// under their key or any of their aliases, split on commas.
+func withSplitValues(values url.Values, fields []valueField) url.Values {
+	var newValues url.Values
+	for _, f := range fields {
+		if !f.slice {
+			continue
+		}
+		for _, key := range append([]string{f.key}, f.aliases...) {
+			if len(values[key]) == 0 {
+				continue
+			}
+			if newValues == nil {
+				newValues = make(url.Values, len(values))
+				for k, vals := range values {
+					newValues[k] = vals
+				}
+			}
+			var vals []string
+			for _, val := range values[key] {
+				for _, v := range strings.Split(val, ",") {
+					if v = strings.TrimSpace(v); v != "" {
+						vals = append(vals, v)
+					}
+				}
+			}
+			newValues[key] = vals
+		}
+	}
+	if newValues == nil {
+		return values
+	}
+	return newValues
+}
+
+// setByUnmarshalers reports whether f is set by setUnmarshalers instead of the
+// form decoder.
+func (f valueField) setByUnmarshalers() bool {
+	return f.unmarshaler || f.json || f.enum || f.text || f.scanner || f.bytesEncoding != ""
+}
+
+// withDelimValues returns a copy of values with the values of fields with a
+// delim tag split on the delimiter, e.g. ids=1,2&ids=3 becomes
+// ids=1&ids=2&ids=3. values is returned unchanged if there are no such fields.
+func withDelimValues(values url.Values, fields []valueField) url.Values {
+	var newValues url.Values
+	for _, f := range fields {
+		if f.delim == "" || len(values[f.key]) == 0 {
+			continue
+		}
+		if newValues == nil {
+			newValues = make(url.Values, len(values))
+			for k, vals := range values {
+				newValues[k] = vals
+			}
+		}
+		var vals []string
+		for _, val := range values[f.key] {
+			vals = append(vals, strings.Split(val, f.delim)...)
+		}
+		newValues[f.key] = vals
+	}
+	if newValues == nil {
+		return values
+	}
+	return newValues
+}
+
+// withoutUnmarshalers splits the values of json.Unmarshaler, enum and []byte
+// fields from values because the form decoder can't handle them.
+func withoutUnmarshalers(values url.Values, fields []valueField) (url.Values, url.Values) {
+	var newValues, unmarshalerValues url.Values
+	for _, f := range fields {
+		if !f.setByUnmarshalers() || len(values[f.key]) == 0 {
+			continue
+		}
+		if newValues == nil {
+			newValues = make(url.Values, len(values))
+			for k, vals := range values {
+				newValues[k] = vals
+			}
+			unmarshalerValues = make(url.Values)
+		}
+		unmarshalerValues[f.key] = values[f.key]
+		delete(newValues, f.key)
+	}
+	if newValues == nil {
+		return values, nil
+	}
+	return newValues, unmarshalerValues
+}
+
+// setUnmarshalers sets the json.Unmarshaler, json option, enum, scanner and
+// []byte fields of v.
+func setUnmarshalers(v any, fields []valueField, values url.Values) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	// like the form decoder, accept and allocate pointers to struct pointers
+	rv := reflect.ValueOf(v).Elem()
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		rv = rv.Elem()
+	}
+	var errs form.DecodeErrors
+	for _, f := range fields {
+		if !f.setByUnmarshalers() || len(values[f.key]) == 0 || values[f.key][0] == "" {
+			continue
+		}
+		fv, ok := fieldByIndexAlloc(rv, f.index)
+		if !ok {
+			continue
+		}
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				fv.Set(reflect.New(fv.Type().Elem()))
+			}
+			fv = fv.Elem()
+		}
+		var err error
+		if f.json {
+			err = json.Unmarshal([]byte(values[f.key][0]), fv.Addr().Interface())
+		} else if f.enum || f.text {
+			err = unmarshalText(values[f.key][0], f.intBase, fv)
+		} else if f.scanner {
+			// time values are already normalized by withTimeLayouts
+			err = scanValue(values[f.key][0], time.RFC3339Nano, fv)
+		} else if f.bytesEncoding != "" {
+			err = setBytesField(values[f.key][0], f.bytesEncoding, fv)
+		} else {
+			err = unmarshalJSONValue(fv.Addr().Interface().(json.Unmarshaler), values[f.key][0])
+		}
+		if err != nil {
+			if errs == nil {
+				errs = make(form.DecodeErrors)
+			}
+			errs[f.key] = err
 		}
 	}
-
-	if err := Header(r, v, flags...); err != nil {
-		return err
+	if errs != nil {
+		return errs
 	}
+	return nil
+}
 
-	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodDelete {
-		if err := Query(r, v, flags...); err != nil {
-			return err
+// unmarshalJSONValue passes val to u as is if it's valid JSON, e.g. a number,
+// and as a JSON string otherwise or if that fails.
+func unmarshalJSONValue(u json.Unmarshaler, val string) error {
+	if json.Valid([]byte(val)) {
+		if err := u.UnmarshalJSON([]byte(val)); err == nil {
+			return nil
 		}
-	} else if err := Body(r, v, flags...); err != nil {
-		return err
 	}
-
-	if validator, ok := v.(Validator); ok {
-		return validator.ValidateBind()
+	quoted, err := json.Marshal(val)
+	if err != nil {
+		return err
 	}
-
-	return nil
+	return u.UnmarshalJSON(quoted)
 }
 
-func Query(r *http.Request, v any, flags ...Flag) error {
-	return DecodeQuery(r.URL.Query(), v, flags...)
+func arrayLen(t reflect.Type) int {
+	if t.Kind() != reflect.Array {
+		return 0
+	}
+	return t.Len()
 }
 
-func Body(r *http.Request, v any, flags ...Flag) error {
-	if r.ContentLength == 0 {
-		return nil
+// checkArrayLen returns an error for array fields with more values than the
+// array can hold; the form decoder silently drops them.
+func checkArrayLen(values url.Values, fields []valueField) error {
+	var errs form.DecodeErrors
+	for _, f := range fields {
+		if f.arrayLen > 0 && len(values[f.key]) > f.arrayLen {
+			if errs == nil {
+				errs = make(form.DecodeErrors)
+			}
+			errs[f.key] = &ArrayLengthError{Len: f.arrayLen, Got: len(values[f.key])}
+		}
 	}
-
-	ct := r.Header.Get("Content-Type")
-
-	switch {
-	case strings.HasPrefix(ct, "application/json"):
-		return json.NewDecoder(r.Body).Decode(v)
-	case strings.HasPrefix(ct, "application/xml") || strings.HasPrefix(ct, "text/xml"):
-		return xml.NewDecoder(r.Body).Decode(v)
-	case strings.HasPrefix(ct, "application/x-www-form-urlencoded") || strings.HasPrefix(ct, "multipart/form-data"):
-		r.ParseForm()
-		return DecodeForm(r.Form, v, flags...)
+	if errs != nil {
+		return errs
 	}
 	return nil
 }
 
-func Header(r *http.Request, v any, flags ...Flag) error {
-	return DecodeHeader(r.Header, v, flags...)
-}
-
-func Path(r *http.Request, v any, flags ...Flag) error {
-	if PathValueFunc == nil {
-		return errors.New("bind: PathValueFunc not set")
-	}
-
-	val := reflect.ValueOf(v)
-	if val.Kind() != reflect.Ptr || val.IsNil() {
-		return &form.InvalidDecoderError{Type: reflect.TypeOf(v)}
+func checkRequired(values url.Values, fields []valueField) error {
+	var errs Errors
+	for _, f := range fields {
+		if f.required && isBlank(values[f.key]) {
+			errs = errs.add(&MissingFieldError{Field: f.field, Name: f.key})
+		}
 	}
-
-	return setPath(r, val)
+	return errs.err()
 }
 
-func vacuum(values url.Values) url.Values {
-	newValues := make(url.Values)
+func trim(values url.Values) url.Values {
+	newValues := make(url.Values, len(values))
 	for key, vals := range values {
-		var newVals []string
-		for _, val := range vals {
-			val = strings.TrimSpace(val)
-			if val != "" {
-				newVals = append(newVals, val)
-			}
-		}
-		if len(newVals) > 0 {
-			newValues[key] = newVals
+		newVals := make([]string, len(vals))
+		for i, val := range vals {
+			newVals[i] = strings.TrimSpace(val)
 		}
+		newValues[key] = newVals
 	}
 	return newValues
 }
@@ -188,7 +1753,57 @@ func hasFlag(flags []Flag, flag Flag) bool {
 	return false
 }
 
-func setPath(r *http.Request, val reflect.Value) error {
+func (b *Binder) setPath(r *http.Request, val reflect.Value, flags []Flag) error {
+	if val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := cachedTagFields(val.Type(), "path")
+	if err := checkTagFields(val.Type(), fields, "path"); err != nil {
+		return err
+	}
+
+	var errs Errors
+
+	for _, f := range fields {
+		fieldVal, ok := fieldByIndexAlloc(val, f.index)
+		if !ok {
+			continue
+		}
+		strVal, found := b.pathValue(r, f.name)
+		if !found && hasFlag(flags, StrictPath) {
+			errs = errs.add(&UnknownPathParamError{Field: f.field, Name: f.name})
+			continue
+		}
+		if strVal == "" {
+			strVal = f.tag.Get("default")
+		}
+		if strVal == "" && isRequired(f.tag) {
+			errs = errs.add(&MissingFieldError{Field: f.field, Name: f.name})
+			continue
+		}
+		// absent params without a default leave the field untouched
+		if strVal == "" && !found {
+			continue
+		}
+		if strVal == "" && hasFlag(flags, NoZeroDefault) && !isStringType(fieldVal.Type()) {
+			continue
+		}
+		if err := b.setField(fieldVal.Kind(), strVal, fieldVal, f.tag); err != nil {
+			errs = errs.add(&FieldError{Field: f.field, Name: f.name, Err: err})
+		}
+	}
+
+	return errs.err()
+}
+
+func (b *Binder) setContext(r *http.Request, val reflect.Value) error {
 	if val.Kind() == reflect.Ptr {
 		if val.IsNil() {
 			return nil
@@ -199,92 +1814,331 @@ func setPath(r *http.Request, val reflect.Value) error {
 		return nil
 	}
 
-	t := val.Type()
+	var errs Errors
+
+	for _, f := range cachedTagFields(val.Type(), "ctx") {
+		key, ok := b.contextKeys[f.name]
+		if !ok {
+			errs = errs.add(fmt.Errorf("bind: no context key registered for %q", f.name))
+			continue
+		}
+		ctxVal := r.Context().Value(key)
+		if ctxVal == nil {
+			if isRequired(f.tag) {
+				errs = errs.add(&MissingFieldError{Field: f.field, Name: f.name})
+			}
+			continue
+		}
+		fieldVal, ok := fieldByIndexAlloc(val, f.index)
+		if !ok {
+			continue
+		}
+		if err := b.setContextField(ctxVal, fieldVal, f); err != nil {
+			errs = errs.add(&FieldError{Field: f.field, Name: f.name, Err: err})
+		}
+	}
+
+	return errs.err()
+}
+
+// setContextField assigns a context value to field if its type allows it,
+// string values are converted with setField.
+func (b *Binder) setContextField(ctxVal any, field reflect.Value, f tagField) error {
+	rv := reflect.ValueOf(ctxVal)
+	switch {
+	case rv.Type().AssignableTo(field.Type()):
+		field.Set(rv)
+		return nil
+	case field.Kind() == reflect.Ptr && rv.Type().AssignableTo(field.Type().Elem()):
+		newVal := reflect.New(field.Type().Elem())
+		newVal.Elem().Set(rv)
+		field.Set(newVal)
+		return nil
+	case rv.Kind() == reflect.String:
+		return b.setField(field.Kind(), rv.String(), field, f.tag)
+	}
+	return fmt.Errorf("can't assign context value of type %T to %s", ctxVal, field.Type())
+}
+
+// fieldByIndexAlloc is like reflect.Value.FieldByIndex but allocates nil
+// struct pointers along the way. It returns false if a nil pointer can't be
+// allocated because it's unexported.
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+type tagField struct {
+	index []int
+	field string
+	name  string
+	tag   reflect.StructTag
+	// unsupported is set for types that can't be bound from a string, see
+	// isUnsupportedType
+	unsupported bool
+}
+
+type tagFieldsCacheKey struct {
+	typ     reflect.Type
+	tagName string
+}
+
+var tagFieldsCache sync.Map // map[tagFieldsCacheKey][]tagField
+
+func cachedTagFields(t reflect.Type, tagName string) []tagField {
+	key := tagFieldsCacheKey{t, tagName}
+	if fields, ok := tagFieldsCache.Load(key); ok {
+		return fields.([]tagField)
+	}
+	fields, _ := tagFieldsCache.LoadOrStore(key, tagFields(t, tagName, nil, "", nil))
+	return fields.([]tagField)
+}
+
+// tagFields returns all fields of struct type t tagged with tagName, including
+// the ones promoted from anonymous struct (pointer) fields. The field name is
+// the full Go path, e.g. Base.ID. It's used for the path and ctx tags. Like
+// valueFields, it doesn't descend into a struct type that is one of parents.
+func tagFields(t reflect.Type, tagName string, index []int, fieldPrefix string, parents []reflect.Type) []tagField {
+	var fields []tagField
+	parents = append(parents[:len(parents):len(parents)], t)
 
-	// TODO cache this
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+
 		if field.Anonymous {
-			setPath(r, val.Field(i))
+			ft := field.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct && !isParentType(ft, parents) {
+				fields = append(fields, tagFields(ft, tagName, fieldIndex, fieldPrefix+field.Name+".", parents)...)
+			}
 			continue
 		}
 
-		pathParam := field.Tag.Get("path")
-		if pathParam != "" && pathParam != "-" {
-			if err := setField(field.Type.Kind(), PathValueFunc(r, pathParam), val.Field(i)); err != nil {
-				return err
-			}
+		name := field.Tag.Get(tagName)
+		if name != "" && name != "-" {
+			fields = append(fields, tagField{
+				index:       fieldIndex,
+				field:       fieldPrefix + field.Name,
+				name:        name,
+				tag:         field.Tag,
+				unsupported: isUnsupportedType(field.Type),
+			})
 		}
 	}
 
-	return nil
+	return fields
 }
 
 // code below is mostly taken from Echo's bind implementation
-func setField(kind reflect.Kind, strVal string, field reflect.Value) error {
+func (b *Binder) setField(kind reflect.Kind, strVal string, field reflect.Value, tag reflect.StructTag) error {
+	if fn, ok := b.types[field.Type()]; ok {
+		return setCustomField(fn, strVal, field)
+	}
+
+	// time.Time is a TextUnmarshaler but we want to honor the format tag
+	if field.Type() == timeType {
+		layout := tag.Get("format")
+		if layout == "" {
+			layout = b.timeFormat
+		}
+		return setTimeField(strVal, layout, field)
+	}
+
+	if field.Type() == durationType {
+		d, err := parseDuration(strVal)
+		if err == nil {
+			field.SetInt(int64(d))
+		}
+		return err
+	}
+
+	// types that know how to parse themselves take precedence over their kind
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		return unmarshalText(strVal, intBase(field.Type(), tag), field)
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(jsonUnmarshalerType) {
+		return unmarshalJSONValue(field.Addr().Interface().(json.Unmarshaler), strVal)
+	}
+	if field.CanAddr() && isScannerType(field.Type()) {
+		if strVal == "" {
+			return nil
+		}
+		layout := tag.Get("format")
+		if layout == "" {
+			layout = b.timeFormat
+		}
+		return scanValue(strVal, layout, field)
+	}
+
 	switch kind {
 	case reflect.Ptr:
 		if field.IsNil() {
 			// TODO avoid unnecessary allocation?
 			newVal := reflect.New(field.Type().Elem())
-			err := setField(newVal.Elem().Kind(), strVal, newVal.Elem())
+			err := b.setField(newVal.Elem().Kind(), strVal, newVal.Elem(), tag)
 			if err == nil {
 				field.Set(newVal)
 			}
 			return err
 		}
-		return setField(field.Elem().Kind(), strVal, field.Elem())
+		return b.setField(field.Elem().Kind(), strVal, field.Elem(), tag)
 	case reflect.Int:
-		return setIntField(strVal, 0, field)
+		return setIntField(strVal, intBase(field.Type(), tag), 0, field)
 	case reflect.Int8:
-		return setIntField(strVal, 8, field)
+		return setIntField(strVal, intBase(field.Type(), tag), 8, field)
 	case reflect.Int16:
-		return setIntField(strVal, 16, field)
+		return setIntField(strVal, intBase(field.Type(), tag), 16, field)
 	case reflect.Int32:
-		return setIntField(strVal, 32, field)
+		return setIntField(strVal, intBase(field.Type(), tag), 32, field)
 	case reflect.Int64:
-		return setIntField(strVal, 64, field)
+		return setIntField(strVal, intBase(field.Type(), tag), 64, field)
 	case reflect.Uint:
-		return setUintField(strVal, 0, field)
+		return setUintField(strVal, intBase(field.Type(), tag), 0, field)
 	case reflect.Uint8:
-		return setUintField(strVal, 8, field)
+		return setUintField(strVal, intBase(field.Type(), tag), 8, field)
 	case reflect.Uint16:
-		return setUintField(strVal, 16, field)
+		return setUintField(strVal, intBase(field.Type(), tag), 16, field)
 	case reflect.Uint32:
-		return setUintField(strVal, 32, field)
+		return setUintField(strVal, intBase(field.Type(), tag), 32, field)
 	case reflect.Uint64:
-		return setUintField(strVal, 64, field)
+		return setUintField(strVal, intBase(field.Type(), tag), 64, field)
 	case reflect.Bool:
 		return setBoolField(strVal, field)
 	case reflect.Float32:
 		return setFloatField(strVal, 32, field)
 	case reflect.Float64:
 		return setFloatField(strVal, 64, field)
+	case reflect.Complex64:
+		return setComplexField(strVal, 64, field)
+	case reflect.Complex128:
+		return setComplexField(strVal, 128, field)
 	case reflect.String:
 		field.SetString(strVal)
+	case reflect.Slice:
+		if enc := bytesEncoding(field.Type(), tag); enc != "" {
+			return setBytesField(strVal, enc, field)
+		}
+		return b.setSliceField(strVal, tag, field)
+	case reflect.Array:
+		return b.setArrayField(strVal, tag, field)
 	default:
+		// uintptr, maps, structs, etc. can't be sensibly bound from a string
 		// TODO return structured error with type information
 		return errors.New("bind: unknown type")
 	}
 	return nil
 }
 
-func setIntField(val string, bitSize int, field reflect.Value) error {
+func setCustomField(fn TypeFunc, val string, field reflect.Value) error {
+	customVal, err := fn(val)
+	if err != nil {
+		return err
+	}
+	if v := reflect.ValueOf(customVal); v.IsValid() && v.Type().AssignableTo(field.Type()) {
+		field.Set(v)
+		return nil
+	}
+	return fmt.Errorf("bind: type func for %s returned %T", field.Type(), customVal)
+}
+
+// splitField splits val on the delim tag value, the Binder separator or a
+// comma.
+func (b *Binder) splitField(val string, tag reflect.StructTag) []string {
+	delim := tag.Get("delim")
+	if delim == "" {
+		delim = b.separator
+	}
+	if delim == "" {
+		delim = ","
+	}
+	return strings.Split(val, delim)
+}
+
+func (b *Binder) setSliceField(val string, tag reflect.StructTag, field reflect.Value) error {
+	if val == "" {
+		field.Set(reflect.Zero(field.Type()))
+		return nil
+	}
+	parts := b.splitField(val, tag)
+	sliceVal := reflect.MakeSlice(field.Type(), len(parts), len(parts))
+	for i, part := range parts {
+		elem := sliceVal.Index(i)
+		if err := b.setField(elem.Kind(), part, elem, tag); err != nil {
+			return err
+		}
+	}
+	field.Set(sliceVal)
+	return nil
+}
+
+func (b *Binder) setArrayField(val string, tag reflect.StructTag, field reflect.Value) error {
+	arrayVal := reflect.New(field.Type()).Elem()
+	if val == "" {
+		field.Set(arrayVal)
+		return nil
+	}
+	parts := b.splitField(val, tag)
+	if len(parts) > arrayVal.Len() {
+		return &ArrayLengthError{Len: arrayVal.Len(), Got: len(parts)}
+	}
+	for i, part := range parts {
+		elem := arrayVal.Index(i)
+		if err := b.setField(elem.Kind(), part, elem, tag); err != nil {
+			return err
+		}
+	}
+	field.Set(arrayVal)
+	return nil
+}
+
+// parseDuration parses a duration like 1h30m or a plain number of
+// nanoseconds.
+func parseDuration(val string) (time.Duration, error) {
+	if val == "" {
+		return 0, nil
+	}
+	if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+		return time.Duration(n), nil
+	}
+	return time.ParseDuration(val)
+}
+
+func setIntField(val string, base, bitSize int, field reflect.Value) error {
 	if val == "" {
 		val = "0"
 	}
-	intVal, err := strconv.ParseInt(val, 10, bitSize)
+	intVal, err := strconv.ParseInt(val, base, bitSize)
+	if errors.Is(err, strconv.ErrRange) {
+		return newRangeError(val, field.Type())
+	}
 	if err == nil {
 		field.SetInt(intVal)
 	}
 	return err
 }
 
-func setUintField(val string, bitSize int, field reflect.Value) error {
+func setUintField(val string, base, bitSize int, field reflect.Value) error {
 	if val == "" {
 		val = "0"
 	}
-	uintVal, err := strconv.ParseUint(val, 10, bitSize)
+	uintVal, err := strconv.ParseUint(val, base, bitSize)
+	if errors.Is(err, strconv.ErrRange) {
+		return newRangeError(val, field.Type())
+	}
 	if err == nil {
 		field.SetUint(uintVal)
 	}
@@ -292,23 +2146,261 @@ func setUintField(val string, bitSize int, field reflect.Value) error {
 }
 
 func setBoolField(val string, field reflect.Value) error {
-	if val == "" {
-		val = "false"
-	}
-	boolVal, err := strconv.ParseBool(val)
+	boolVal, err := parseBool(val)
 	if err == nil {
 		field.SetBool(boolVal)
 	}
 	return err
 }
 
+// parseBool accepts the same values as the form decoders, which includes
+// checkbox values like "on" and "off".
+func parseBool(val string) (bool, error) {
+	switch val {
+	case "1", "t", "T", "true", "TRUE", "True", "on", "yes", "ok":
+		return true, nil
+	case "", "0", "f", "F", "false", "FALSE", "False", "off", "no":
+		return false, nil
+	}
+	return false, &strconv.NumError{Func: "ParseBool", Num: val, Err: strconv.ErrSyntax}
+}
+
 func setFloatField(val string, bitSize int, field reflect.Value) error {
 	if val == "" {
 		val = "0.0"
 	}
 	floatVal, err := strconv.ParseFloat(val, bitSize)
+	if errors.Is(err, strconv.ErrRange) {
+		return newRangeError(val, field.Type())
+	}
 	if err == nil {
 		field.SetFloat(floatVal)
 	}
 	return err
 }
+
+func setTimeField(val, layout string, field reflect.Value) error {
+	if val == "" {
+		field.Set(reflect.ValueOf(time.Time{}))
+		return nil
+	}
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	timeVal, err := parseTime(layout, val)
+	if err == nil {
+		field.Set(reflect.ValueOf(timeVal))
+	}
+	return err
+}
+
+// Special time layouts for Unix timestamps in seconds and milliseconds, e.g.
+// format:"unix".
+const (
+	unixLayout      = "unix"
+	unixMilliLayout = "unixmilli"
+)
+
+// parseTime is like time.Parse but also supports the unix and unixmilli
+// layouts.
+func parseTime(layout, val string) (time.Time, error) {
+	switch layout {
+	case unixLayout, unixMilliLayout:
+		n, err := strconv.ParseInt(val, 10, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if layout == unixLayout {
+			return time.Unix(n, 0).UTC(), nil
+		}
+		return time.UnixMilli(n).UTC(), nil
+	}
+	return time.Parse(layout, val)
+}
+
+// formatTime is the inverse of parseTime.
+func formatTime(t time.Time, layout string) string {
+	switch layout {
+	case unixLayout:
+		return strconv.FormatInt(t.Unix(), 10)
+	case unixMilliLayout:
+		return strconv.FormatInt(t.UnixMilli(), 10)
+	}
+	return t.Format(layout)
+}
+
+// numericType returns the integer or float type of t, a pointer to it or a
+// slice or array of them. It returns nil for other types.
+func numericType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if isIntKind(t.Kind()) || t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64 {
+		return t
+	}
+	return nil
+}
+
+func isSignedKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+	return false
+}
+
+// newRangeError returns a *RangeError for val and numeric type t.
+func newRangeError(val string, t reflect.Type) *RangeError {
+	e := &RangeError{Value: val, Type: t.String()}
+	switch {
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		max := math.MaxFloat64
+		if t.Kind() == reflect.Float32 {
+			max = math.MaxFloat32
+		}
+		e.Min = strconv.FormatFloat(-max, 'g', -1, t.Bits())
+		e.Max = strconv.FormatFloat(max, 'g', -1, t.Bits())
+	case isSignedKind(t.Kind()):
+		e.Min = strconv.FormatInt(-1<<(t.Bits()-1), 10)
+		e.Max = strconv.FormatInt(1<<(t.Bits()-1)-1, 10)
+	default:
+		e.Min = "0"
+		e.Max = strconv.FormatUint(^uint64(0)>>(64-t.Bits()), 10)
+	}
+	return e
+}
+
+// parseNumber parses val as a number of type t to detect overflows.
+func parseNumber(val string, t reflect.Type) error {
+	var err error
+	switch {
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		_, err = strconv.ParseFloat(val, t.Bits())
+	case isSignedKind(t.Kind()):
+		_, err = strconv.ParseInt(val, 10, t.Bits())
+	default:
+		_, err = strconv.ParseUint(val, 10, t.Bits())
+	}
+	return err
+}
+
+// checkRanges returns a *RangeError for numeric values that don't fit their
+// field type, instead of the less helpful error of the form decoder. Other
+// parse errors are left to the form decoder.
+func checkRanges(values url.Values, fields []valueField) error {
+	var errs form.DecodeErrors
+	for _, f := range fields {
+		if f.numType == nil || f.setByUnmarshalers() {
+			continue
+		}
+		for _, val := range values[f.key] {
+			if val == "" {
+				continue
+			}
+			if err := parseNumber(val, f.numType); errors.Is(err, strconv.ErrRange) {
+				if errs == nil {
+					errs = make(form.DecodeErrors)
+				}
+				errs[f.key] = newRangeError(val, f.numType)
+				break
+			}
+		}
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+func setComplexField(val string, bitSize int, field reflect.Value) error {
+	if val == "" {
+		val = "0"
+	}
+	complexVal, err := strconv.ParseComplex(val, bitSize)
+	if err == nil {
+		field.SetComplex(complexVal)
+	}
+	return err
+}
+
+// formatField is the inverse of setField.
+func (b *Binder) formatField(field reflect.Value, tag reflect.StructTag) (string, error) {
+	// *time.Time is a TextMarshaler too, but has to honor the format tag
+	if field.Kind() == reflect.Ptr && field.Type().Elem() == timeType {
+		if field.IsNil() {
+			return "", nil
+		}
+		field = field.Elem()
+	}
+	if field.Type() == timeType {
+		layout := tag.Get("format")
+		if layout == "" {
+			layout = b.timeFormat
+		}
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		return formatTime(field.Interface().(time.Time), layout), nil
+	}
+
+	if field.Type().Implements(textMarshalerType) {
+		if field.Kind() == reflect.Ptr && field.IsNil() {
+			return "", nil
+		}
+		b, err := field.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+	if field.CanAddr() && field.Addr().Type().Implements(textMarshalerType) {
+		b, err := field.Addr().Interface().(encoding.TextMarshaler).MarshalText()
+		return string(b), err
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		if field.IsNil() {
+			return "", nil
+		}
+		return b.formatField(field.Elem(), tag)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(field.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(field.Uint(), 10), nil
+	case reflect.Bool:
+		return strconv.FormatBool(field.Bool()), nil
+	case reflect.Float32:
+		return strconv.FormatFloat(field.Float(), 'f', -1, 32), nil
+	case reflect.Float64:
+		return strconv.FormatFloat(field.Float(), 'f', -1, 64), nil
+	case reflect.Complex64:
+		return strconv.FormatComplex(field.Complex(), 'f', -1, 64), nil
+	case reflect.Complex128:
+		return strconv.FormatComplex(field.Complex(), 'f', -1, 128), nil
+	case reflect.String:
+		return field.String(), nil
+	case reflect.Slice, reflect.Array:
+		if enc := bytesEncoding(field.Type(), tag); enc != "" {
+			if enc == "hex" {
+				return hex.EncodeToString(field.Bytes()), nil
+			}
+			return base64.StdEncoding.EncodeToString(field.Bytes()), nil
+		}
+		delim := tag.Get("delim")
+		if delim == "" {
+			delim = b.separator
+		}
+		if delim == "" {
+			delim = ","
+		}
+		parts := make([]string, field.Len())
+		for i := range parts {
+			part, err := b.formatField(field.Index(i), tag)
+			if err != nil {
+				return "", err
+			}
+			parts[i] = part
+		}
+		return strings.Join(parts, delim), nil
+	}
+
+	return "", errors.New("bind: unknown type")
+}
@@ -1,8 +1,27 @@
 package bind
 
 import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-playground/form/v4"
 )
 
 func TestPath(t *testing.T) {
@@ -26,12 +45,12 @@ func TestPath(t *testing.T) {
 		ID t1 `path:"id"`
 	}
 
-	PathValueFunc = func(r *http.Request, k string) string {
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
 		if k == "id" {
 			return "123"
 		}
 		return ""
-	}
+	})()
 
 	r, _ := http.NewRequest(http.MethodGet, "/", nil)
 
@@ -101,3 +120,2690 @@ func TestPath(t *testing.T) {
 		t.Error("got nil, want error")
 	}
 }
+
+type testDate struct {
+	time.Time
+}
+
+func (d *testDate) UnmarshalText(b []byte) error {
+	t, err := time.Parse("2006-01-02", string(b))
+	if err == nil {
+		d.Time = t
+	}
+	return err
+}
+
+func TestPathTextUnmarshaler(t *testing.T) {
+	type t1 struct {
+		Date testDate `path:"date"`
+	}
+
+	type t2 struct {
+		Date *testDate `path:"date"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		if k == "date" {
+			return "2023-01-02"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	want := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+
+	v1 := t1{}
+	if err := Path(r, &v1); err != nil {
+		t.Error(err)
+	} else if !v1.Date.Equal(want) {
+		t.Errorf("got %v, want %v", v1.Date, want)
+	}
+
+	// allocate nil pointer before unmarshaling
+	v2 := t2{}
+	if err := Path(r, &v2); err != nil {
+		t.Error(err)
+	} else if v2.Date == nil {
+		t.Errorf("got nil, want %v", want)
+	} else if !v2.Date.Equal(want) {
+		t.Errorf("got %v, want %v", v2.Date, want)
+	}
+}
+
+func TestBytes(t *testing.T) {
+	type t1 struct {
+		Data []byte `query:"data" path:"data"`
+		Hash []byte `query:"hash" encoding:"hex"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "aGVsbG8="
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?data=aGVsbG8%3D&hash=cafe", nil)
+
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if string(v.Data) != "hello" {
+		t.Errorf("got %q, want %q", v.Data, "hello")
+	}
+	if !bytes.Equal(v.Hash, []byte{0xca, 0xfe}) {
+		t.Errorf("got %x, want %x", v.Hash, []byte{0xca, 0xfe})
+	}
+
+	v = t1{}
+	if err := Path(r, &v); err != nil {
+		t.Fatal(err)
+	} else if string(v.Data) != "hello" {
+		t.Errorf("got %q, want %q", v.Data, "hello")
+	}
+
+	params, err := EncodePath(t1{Data: []byte("hello")})
+	if err != nil {
+		t.Fatal(err)
+	} else if params["data"] != "aGVsbG8=" {
+		t.Errorf("got %q, want %q", params["data"], "aGVsbG8=")
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?hash=xyz", nil)
+	if err := Query(r, &t1{}); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestBytesUnmarshalers(t *testing.T) {
+	type t1 struct {
+		Meta json.RawMessage `query:"meta" path:"meta"`
+		IP   net.IP          `query:"ip"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return `{"a":1}`
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?meta=%7B%22a%22%3A1%7D&ip=127.0.0.1", nil)
+
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if string(v.Meta) != `{"a":1}` {
+		t.Errorf("got %s, want %s", v.Meta, `{"a":1}`)
+	}
+	if !v.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("got %v, want 127.0.0.1", v.IP)
+	}
+
+	v = t1{}
+	if err := Path(r, &v); err != nil {
+		t.Fatal(err)
+	} else if string(v.Meta) != `{"a":1}` {
+		t.Errorf("got %s, want %s", v.Meta, `{"a":1}`)
+	}
+}
+
+func TestDuration(t *testing.T) {
+	type t1 struct {
+		Timeout  time.Duration  `path:"timeout" query:"timeout" form:"timeout"`
+		Interval *time.Duration `query:"interval"`
+	}
+
+	want := 90 * time.Minute
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "1h30m"
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?timeout=1h30m&interval=1000", nil)
+
+	v := t1{}
+	if err := Path(r, &v); err != nil {
+		t.Fatal(err)
+	} else if v.Timeout != want {
+		t.Errorf("got %v, want %v", v.Timeout, want)
+	}
+
+	v = t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	} else if v.Timeout != want {
+		t.Errorf("got %v, want %v", v.Timeout, want)
+	} else if v.Interval == nil || *v.Interval != time.Microsecond {
+		t.Errorf("got %v, want %v", v.Interval, time.Microsecond)
+	}
+
+	v = t1{}
+	if err := DecodeForm(url.Values{"timeout": {"1h30m"}}, &v); err != nil {
+		t.Fatal(err)
+	} else if v.Timeout != want {
+		t.Errorf("got %v, want %v", v.Timeout, want)
+	}
+
+	if err := DecodeQuery(url.Values{"timeout": {"soon"}}, &t1{}); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestPathTime(t *testing.T) {
+	type t1 struct {
+		CreatedAt time.Time `path:"created_at"`
+	}
+
+	type t2 struct {
+		CreatedAt *time.Time `path:"date" format:"2006-01-02"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		switch k {
+		case "created_at":
+			return "2023-01-02T15:04:05Z"
+		case "date":
+			return "2023-01-02"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	// RFC3339 by default
+	v1 := t1{}
+	want := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC)
+	if err := Path(r, &v1); err != nil {
+		t.Error(err)
+	} else if !v1.CreatedAt.Equal(want) {
+		t.Errorf("got %v, want %v", v1.CreatedAt, want)
+	}
+
+	// custom layout
+	v2 := t2{}
+	want = time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+	if err := Path(r, &v2); err != nil {
+		t.Error(err)
+	} else if v2.CreatedAt == nil {
+		t.Errorf("got nil, want %v", want)
+	} else if !v2.CreatedAt.Equal(want) {
+		t.Errorf("got %v, want %v", v2.CreatedAt, want)
+	}
+}
+
+func TestNoZeroDefault(t *testing.T) {
+	type t1 struct {
+		Count int     `path:"count" query:"count"`
+		Limit *int    `path:"limit" query:"limit"`
+		Name  *string `path:"name"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?count=&limit=", nil)
+
+	// empty values are zero by default
+	v := t1{Count: 5}
+	if err := Path(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Count != 0 || v.Limit == nil || *v.Limit != 0 || v.Name == nil {
+		t.Errorf("got %+v, want zero values", v)
+	}
+
+	// and left untouched with NoZeroDefault
+	v = t1{Count: 5}
+	if err := Path(r, &v, NoZeroDefault); err != nil {
+		t.Fatal(err)
+	}
+	if v.Count != 5 || v.Limit != nil || v.Name == nil {
+		t.Errorf("got %+v, want untouched numbers", v)
+	}
+
+	v = t1{Count: 5}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Count != 5 || v.Limit != nil {
+		t.Errorf("got %+v, want untouched numbers", v)
+	}
+}
+
+type status int
+
+func (s *status) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "inactive":
+		*s = 0
+	case "active":
+		*s = 1
+	default:
+		return fmt.Errorf("invalid status %q", text)
+	}
+	return nil
+}
+
+func TestEnum(t *testing.T) {
+	type t1 struct {
+		PathStatus  status  `path:"status"`
+		QueryStatus *status `query:"status"`
+		Other       status  `query:"other"`
+	}
+
+	var val string
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return val
+	})()
+
+	for _, val = range []string{"active", "1"} {
+		r, _ := http.NewRequest(http.MethodGet, "/?status="+val+"&other="+val, nil)
+
+		v := t1{}
+		if err := Request(r, &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.PathStatus != 1 || v.QueryStatus == nil || *v.QueryStatus != 1 || v.Other != 1 {
+			t.Errorf("%s: got %+v, want status 1", val, v)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?status=unknown", nil)
+	err := Query(r, &t1{})
+	if err == nil || !strings.Contains(err.Error(), `invalid status "unknown"`) {
+		t.Errorf("got %v, want UnmarshalText error", err)
+	}
+}
+
+func TestEnumIntBase(t *testing.T) {
+	type t1 struct {
+		Status status `path:"status" query:"status" base:"16"`
+	}
+
+	var val string
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return val
+	})()
+
+	for _, val = range []string{"active", "1"} {
+		r, _ := http.NewRequest(http.MethodGet, "/?status="+val, nil)
+
+		v := t1{}
+		if err := Query(r, &v); err != nil {
+			t.Fatal(err)
+		} else if v.Status != 1 {
+			t.Errorf("%s: got %d, want 1", val, v.Status)
+		}
+
+		v = t1{}
+		if err := Path(r, &v); err != nil {
+			t.Fatal(err)
+		} else if v.Status != 1 {
+			t.Errorf("%s: got %d, want 1", val, v.Status)
+		}
+	}
+
+	// numbers are parsed in the base of the field
+	r, _ := http.NewRequest(http.MethodGet, "/?status=ff", nil)
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	} else if v.Status != 255 {
+		t.Errorf("got %d, want 255", v.Status)
+	}
+}
+
+func TestRangeError(t *testing.T) {
+	type t1 struct {
+		Small  int8      `path:"small" query:"small"`
+		Count  uint16    `query:"count"`
+		Ratios []float32 `query:"ratio"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "99999"
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	err := Path(r, &t1{})
+	var e *RangeError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want *RangeError", err)
+	}
+	if want := (RangeError{Value: "99999", Type: "int8", Min: "-128", Max: "127"}); *e != want {
+		t.Errorf("got %+v, want %+v", e, want)
+	}
+	if got, want := e.Error(), "bind: value 99999 out of range for int8, must be between -128 and 127"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if !strings.Contains(err.Error(), "Small") || !strings.Contains(err.Error(), "between -128 and 127") {
+		t.Errorf("got %q, want field name and range", err)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?small=1&ratio=1&ratio=1e39", nil)
+	err = Query(r, &t1{})
+	var errs form.DecodeErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("got %T, want form.DecodeErrors", err)
+	}
+	if _, ok := errs["small"]; ok {
+		t.Errorf("got error for small, want none")
+	}
+	if !errors.As(errs["ratio"], &e) || e.Type != "float32" {
+		t.Errorf("got %v, want *RangeError for ratio", errs["ratio"])
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?count=70000", nil)
+	err = Query(r, &t1{})
+	if !errors.As(err, &errs) || !errors.As(errs["count"], &e) || e.Max != "65535" {
+		t.Errorf("got %v, want *RangeError with max 65535", err)
+	}
+}
+
+func TestIntBase(t *testing.T) {
+	type t1 struct {
+		ID    int     `path:"id" base:"0"`
+		Mask  uint8   `path:"mask" base:"0"`
+		Color *uint32 `query:"color" base:"16"`
+		Flags []int   `query:"flags" base:"0"`
+		Count int     `query:"count"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		switch k {
+		case "id":
+			return "0x1F"
+		case "mask":
+			return "0b101"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?color=ff00ff&flags=0o17&flags=-0x2&flags=7&count=010", nil)
+
+	v := t1{}
+	if err := Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 31 || v.Mask != 5 {
+		t.Errorf("got id %d and mask %d, want 31 and 5", v.ID, v.Mask)
+	}
+	if v.Color == nil || *v.Color != 0xff00ff {
+		t.Errorf("got %v, want %d", v.Color, 0xff00ff)
+	}
+	if !reflect.DeepEqual(v.Flags, []int{15, -2, 7}) {
+		t.Errorf("got %v, want [15 -2 7]", v.Flags)
+	}
+	// base 10 is the default
+	if v.Count != 10 {
+		t.Errorf("got %d, want 10", v.Count)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?color=0xff", nil)
+	if err := Query(r, &v); err == nil {
+		t.Error("expected error for prefix with explicit base")
+	}
+
+	// range errors hold the value as sent
+	r, _ = http.NewRequest(http.MethodGet, "/?flags=0xFFFFFFFFFFFFFFFFF", nil)
+	var errs form.DecodeErrors
+	var e *RangeError
+	if err := Query(r, &t1{}); !errors.As(err, &errs) || !errors.As(errs["flags"], &e) || e.Value != "0xFFFFFFFFFFFFFFFFF" {
+		t.Errorf("got %v, want *RangeError for 0xFFFFFFFFFFFFFFFFF", err)
+	}
+
+	type t2 struct {
+		Small int8 `query:"small" base:"0"`
+	}
+	r, _ = http.NewRequest(http.MethodGet, "/?small=0xFFF", nil)
+	if err := Query(r, &t2{}); !errors.As(err, &errs) || !errors.As(errs["small"], &e) || *e != (RangeError{Value: "0xFFF", Type: "int8", Min: "-128", Max: "127"}) {
+		t.Errorf("got %v, want *RangeError for 0xFFF", err)
+	}
+}
+
+func TestStrictPath(t *testing.T) {
+	type t1 struct {
+		ID   int    `path:"id"`
+		Slug string `path:"slug"`
+	}
+
+	b := New()
+	b.PathValueFuncOk = func(r *http.Request, k string) (string, bool) {
+		if k == "id" {
+			return "1", true
+		}
+		return "", false
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	v := t1{}
+	if err := b.Path(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 {
+		t.Errorf("got %d, want 1", v.ID)
+	}
+
+	err := b.Path(r, &v, StrictPath)
+	var e *UnknownPathParamError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want *UnknownPathParamError", err)
+	}
+	if e.Name != "slug" || e.Field != "Slug" {
+		t.Errorf("got %+v", e)
+	}
+	if StatusCode(err) != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", StatusCode(err))
+	}
+}
+
+func TestPathValueFuncOk(t *testing.T) {
+	type t1 struct {
+		ID    int    `path:"id"`
+		Page  int    `path:"page" default:"1"`
+		Count int    `path:"count"`
+		Slug  string `path:"slug"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "2"
+	})()
+	PathValueFuncOk = func(r *http.Request, k string) (string, bool) {
+		if k == "id" {
+			return "3", true
+		}
+		return "", false
+	}
+	defer func() { PathValueFuncOk = nil }()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	v := t1{Count: 5, Slug: "x"}
+	if err := Path(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	want := t1{ID: 3, Page: 1, Count: 5, Slug: "x"}
+	if v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestPathFieldError(t *testing.T) {
+	type Base struct {
+		ID int `path:"id"`
+	}
+	type t1 struct {
+		Base
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "x"
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	err := Path(r, &t1{})
+	var e *FieldError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %T, want *FieldError", err)
+	}
+	if e.Field != "Base.ID" || e.Name != "id" {
+		t.Errorf("got %+v", e)
+	}
+	var numErr *strconv.NumError
+	if !errors.As(err, &numErr) {
+		t.Errorf("got %v, want wrapped *strconv.NumError", err)
+	}
+}
+
+func TestPathErrors(t *testing.T) {
+	type t1 struct {
+		A int `path:"a"`
+		B int `path:"b"`
+	}
+
+	type t2 struct {
+		A int `path:"a"`
+		C int `path:"c"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		switch k {
+		case "a", "b":
+			return "x"
+		case "c":
+			return "1"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	// all errors are collected
+	v1 := t1{}
+	err := Path(r, &v1)
+	if errs, ok := err.(Errors); !ok {
+		t.Errorf("got %T, want Errors", err)
+	} else if len(errs) != 2 {
+		t.Errorf("got %d errors, want 2", len(errs))
+	}
+
+	// a single error is returned as is
+	v2 := t2{}
+	err = Path(r, &v2)
+	if err == nil {
+		t.Error("got nil, want error")
+	} else if _, ok := err.(Errors); ok {
+		t.Errorf("got Errors, want single error")
+	}
+	if v2.C != 1 {
+		t.Errorf("got %d, want %d", v2.C, 1)
+	}
+}
+
+func TestBodyStrict(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	newRequest := func() *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"x","nam":"y"}`))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	// unknown fields are ignored by default
+	v1 := t1{}
+	if err := Body(newRequest(), &v1); err != nil {
+		t.Error(err)
+	} else if v1.Name != "x" {
+		t.Errorf("got %q, want %q", v1.Name, "x")
+	}
+
+	// and rejected in strict mode
+	v2 := t1{}
+	if err := Body(newRequest(), &v2, Strict); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestBodyUseNumber(t *testing.T) {
+	type t1 struct {
+		ID any `json:"id"`
+	}
+
+	const id = "1234567890123456789"
+
+	newRequest := func() *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":`+id+`}`))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	// numbers are decoded as float64 by default
+	v1 := t1{}
+	if err := Body(newRequest(), &v1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := v1.ID.(float64); !ok {
+		t.Errorf("got %T, want float64", v1.ID)
+	}
+
+	v2 := t1{}
+	if err := Body(newRequest(), &v2, UseNumber); err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := v2.ID.(json.Number); !ok || n.String() != id {
+		t.Errorf("got %v, want %s", v2.ID, id)
+	}
+}
+
+func TestBodyMaxBytes(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	defer func(n int64) { MaxBodyBytes = n }(MaxBodyBytes)
+	MaxBodyBytes = 8
+
+	newRequest := func() *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"too long"}`))
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	v := t1{}
+	err := Body(newRequest(), &v)
+	if !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("got %v, want ErrBodyTooLarge", err)
+	}
+	var tooLargeErr *BodyTooLargeError
+	if !errors.As(err, &tooLargeErr) || tooLargeErr.Limit != 8 {
+		t.Errorf("got %v, want *BodyTooLargeError with limit 8", err)
+	}
+	// the original error is wrapped
+	var maxBytesErr *http.MaxBytesError
+	if !errors.As(err, &maxBytesErr) {
+		t.Errorf("got %v, want *http.MaxBytesError", err)
+	}
+
+	if err := Body(newRequest(), &v, NoBodyLimit); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHeaderCanonical(t *testing.T) {
+	type t1 struct {
+		APIKey    string `header:"x-api-key"`
+		RequestID string `header:"X-Request-Id"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-API-KEY", "secret")
+	r.Header.Set("x-request-id", "1")
+
+	v := t1{}
+	if err := Header(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (t1{APIKey: "secret", RequestID: "1"}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	header, err := EncodeHeader(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := header.Get("X-Api-Key"); got != "secret" {
+		t.Errorf("got %q, want %q", got, "secret")
+	}
+}
+
+func TestHeaderRepeated(t *testing.T) {
+	type t1 struct {
+		Tags      []string `header:"X-Tag"`
+		Languages []string `header:"accept-language"`
+		IDs       []int    `header:"X-Id"`
+		First     string   `header:"X-First"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Add("X-Tag", "a")
+	r.Header.Add("X-Tag", "b")
+	r.Header.Add("X-Tag", "c")
+	r.Header.Add("Accept-Language", "nl")
+	r.Header.Add("Accept-Language", "en;q=0.8")
+	r.Header.Add("X-Id", "1")
+	r.Header.Add("X-Id", "2")
+	r.Header.Add("X-First", "1")
+	r.Header.Add("X-First", "2")
+
+	v := t1{}
+	if err := Header(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	want := t1{
+		Tags:      []string{"a", "b", "c"},
+		Languages: []string{"nl", "en;q=0.8"},
+		IDs:       []int{1, 2},
+		First:     "1",
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestHeaderSplit(t *testing.T) {
+	type t1 struct {
+		ForwardedFor []string `header:"X-Forwarded-For"`
+		RequestID    string   `header:"X-Request-Id"`
+		Languages    []string `header:"X-Languages,alias=Accept-Language"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Add("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+	r.Header.Add("X-Forwarded-For", "10.0.0.3")
+	r.Header.Set("X-Request-Id", "a,b")
+	r.Header.Set("Accept-Language", "nl, en")
+
+	v := t1{}
+	if err := Header(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.ForwardedFor) != 2 {
+		t.Errorf("got %q, want 2 elements", v.ForwardedFor)
+	}
+
+	v = t1{}
+	if err := Header(r, &v, SplitHeaders); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"}
+	if !reflect.DeepEqual(v.ForwardedFor, want) {
+		t.Errorf("got %q, want %q", v.ForwardedFor, want)
+	}
+	if v.RequestID != "a,b" {
+		t.Errorf("got %q, want %q", v.RequestID, "a,b")
+	}
+	want = []string{"nl", "en"}
+	if !reflect.DeepEqual(v.Languages, want) {
+		t.Errorf("got %q, want %q", v.Languages, want)
+	}
+}
+
+func TestCookie(t *testing.T) {
+	type t1 struct {
+		SessionID string `cookie:"session_id"`
+		Theme     string `cookie:"theme"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session_id", Value: "abc"})
+
+	v := t1{}
+	if err := Cookie(r, &v); err != nil {
+		t.Error(err)
+	} else if v.SessionID != "abc" {
+		t.Errorf("got %q, want %q", v.SessionID, "abc")
+	} else if v.Theme != "" {
+		t.Errorf("got %q, want %q", v.Theme, "")
+	}
+}
+
+func TestEncodeCookie(t *testing.T) {
+	type t1 struct {
+		SessionID string `cookie:"session_id"`
+		Theme     string `cookie:"theme"`
+		Count     int    `cookie:"count"`
+	}
+
+	cookies, err := EncodeCookie(t1{SessionID: "abc", Count: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// round trip
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range cookies {
+		if c.Value == "" {
+			t.Errorf("got empty cookie %q", c.Name)
+		}
+		r.AddCookie(c)
+	}
+	v := t1{}
+	if err := Cookie(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (t1{SessionID: "abc", Count: 2}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	type t1 struct {
+		Limit  int     `query:"limit" default:"20"`
+		Offset *int    `query:"offset" default:"5"`
+		Sort   string  `query:"sort" default:"id"`
+		Page   int     `path:"page" default:"1"`
+		Filter *string `query:"filter"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return ""
+	})()
+
+	// defaults are applied to absent and, with Vacuum, blank values
+	r, _ := http.NewRequest(http.MethodGet, "/?sort=+&limit=10", nil)
+	v := t1{}
+	if err := Query(r, &v, Vacuum); err != nil {
+		t.Error(err)
+	}
+	if err := Path(r, &v); err != nil {
+		t.Error(err)
+	}
+	if v.Limit != 10 {
+		t.Errorf("got %d, want %d", v.Limit, 10)
+	}
+	if v.Offset == nil || *v.Offset != 5 {
+		t.Errorf("got %v, want %d", v.Offset, 5)
+	}
+	if v.Sort != "id" {
+		t.Errorf("got %q, want %q", v.Sort, "id")
+	}
+	if v.Page != 1 {
+		t.Errorf("got %d, want %d", v.Page, 1)
+	}
+	if v.Filter != nil {
+		t.Errorf("got %q, want nil", *v.Filter)
+	}
+}
+
+func TestRequired(t *testing.T) {
+	type t1 struct {
+		Q     string `query:"q" required:"true"`
+		Limit int    `query:"limit" required:"true" default:"20"`
+		ID    int    `path:"id" required:"true"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?q=+", nil)
+
+	v := t1{}
+	var missingErr *MissingFieldError
+
+	// blank after Vacuum counts as missing, a default satisfies required
+	err := Query(r, &v, Vacuum)
+	if !errors.Is(err, ErrMissingField) {
+		t.Errorf("got %v, want ErrMissingField", err)
+	}
+	if !errors.As(err, &missingErr) {
+		t.Errorf("got %T, want *MissingFieldError", err)
+	} else if missingErr.Field != "Q" || missingErr.Name != "q" {
+		t.Errorf("got %s (%s), want Q (q)", missingErr.Field, missingErr.Name)
+	}
+
+	err = Path(r, &v)
+	if !errors.As(err, &missingErr) {
+		t.Errorf("got %v, want *MissingFieldError", err)
+	} else if missingErr.Field != "ID" || missingErr.Name != "id" {
+		t.Errorf("got %s (%s), want ID (id)", missingErr.Field, missingErr.Name)
+	}
+}
+
+func TestBodyYAML(t *testing.T) {
+	type t1 struct {
+		Name string   `yaml:"name"`
+		Tags []string `yaml:"tags"`
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("name: x\ntags: [a, b]\n"))
+	r.Header.Set("Content-Type", "application/yaml")
+
+	v := t1{}
+	if err := Request(r, &v); err != nil {
+		t.Error(err)
+	} else if v.Name != "x" || len(v.Tags) != 2 {
+		t.Errorf("got %+v, want {Name:x Tags:[a b]}", v)
+	}
+}
+
+func TestBodyTOML(t *testing.T) {
+	type t1 struct {
+		Name string   `toml:"name"`
+		Tags []string `toml:"tags"`
+	}
+
+	newRequest := func(body string) *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/toml")
+		return r
+	}
+
+	v := t1{}
+	if err := Request(newRequest("name = \"x\"\ntags = [\"a\", \"b\"]\n"), &v); err != nil {
+		t.Error(err)
+	} else if v.Name != "x" || len(v.Tags) != 2 {
+		t.Errorf("got %+v, want {Name:x Tags:[a b]}", v)
+	}
+
+	if err := Body(newRequest("nam = \"x\"\n"), &t1{}, Strict); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestPathSlice(t *testing.T) {
+	type t1 struct {
+		IDs   []int    `path:"ids"`
+		Names []string `path:"names" delim:";"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		switch k {
+		case "ids":
+			return "1,2,3"
+		case "names":
+			return "a;b"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	v := t1{}
+	if err := Path(r, &v); err != nil {
+		t.Error(err)
+	}
+	if len(v.IDs) != 3 || v.IDs[0] != 1 || v.IDs[1] != 2 || v.IDs[2] != 3 {
+		t.Errorf("got %v, want %v", v.IDs, []int{1, 2, 3})
+	}
+	if len(v.Names) != 2 || v.Names[0] != "a" || v.Names[1] != "b" {
+		t.Errorf("got %v, want %v", v.Names, []string{"a", "b"})
+	}
+}
+
+func TestPathSlicePointers(t *testing.T) {
+	type t1 struct {
+		IDs     *[]int  `path:"ids"`
+		PtrIDs  []*int  `path:"ids"`
+		PtrsIDs *[]*int `path:"ids"`
+		Missing *[]int  `path:"missing"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		if k == "ids" {
+			return "1,2"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	v := t1{}
+	if err := Path(r, &v, NoZeroDefault); err != nil {
+		t.Fatal(err)
+	}
+	if v.IDs == nil || !reflect.DeepEqual(*v.IDs, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", v.IDs)
+	}
+	if len(v.PtrIDs) != 2 || *v.PtrIDs[0] != 1 || *v.PtrIDs[1] != 2 {
+		t.Errorf("got %v, want [1 2]", v.PtrIDs)
+	}
+	if v.PtrsIDs == nil || len(*v.PtrsIDs) != 2 || *(*v.PtrsIDs)[1] != 2 {
+		t.Errorf("got %v, want [1 2]", v.PtrsIDs)
+	}
+	if v.Missing != nil {
+		t.Errorf("got %v, want nil", v.Missing)
+	}
+}
+
+func TestArray(t *testing.T) {
+	type t1 struct {
+		IDs [3]int `path:"ids" query:"ids"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "1,2,3"
+	})()
+
+	want := [3]int{1, 2, 3}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?ids=1&ids=2&ids=3", nil)
+
+	v := t1{}
+	if err := Path(r, &v); err != nil {
+		t.Fatal(err)
+	} else if v.IDs != want {
+		t.Errorf("got %v, want %v", v.IDs, want)
+	}
+
+	v = t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	} else if v.IDs != want {
+		t.Errorf("got %v, want %v", v.IDs, want)
+	}
+
+	params, err := EncodePath(t1{IDs: want})
+	if err != nil {
+		t.Fatal(err)
+	} else if params["ids"] != "1,2,3" {
+		t.Errorf("got %q, want %q", params["ids"], "1,2,3")
+	}
+
+	// too many values
+	var lenErr *ArrayLengthError
+	r, _ = http.NewRequest(http.MethodGet, "/?ids=1&ids=2&ids=3&ids=4", nil)
+	if err := Query(r, &t1{}); err == nil {
+		t.Error("got nil, want error")
+	} else if errs, ok := err.(form.DecodeErrors); !ok || !errors.As(errs["ids"], &lenErr) {
+		t.Errorf("got %v, want *ArrayLengthError", err)
+	}
+	PathValueFunc = func(r *http.Request, k string) string {
+		return "1,2,3,4"
+	}
+	if err := Path(r, &t1{}); !errors.As(err, &lenErr) {
+		t.Errorf("got %v, want *ArrayLengthError", err)
+	}
+}
+
+func TestEncodePath(t *testing.T) {
+	type t1 struct {
+		ID      int        `path:"id"`
+		Date    time.Time  `path:"date" format:"2006-01-02"`
+		Since   *time.Time `path:"since" format:"2006-01-02"`
+		IDs     []int      `path:"ids"`
+		Missing *string    `path:"missing"`
+		Name    string
+	}
+
+	since := time.Date(2022, 5, 6, 0, 0, 0, 0, time.UTC)
+	v := t1{
+		ID:    1,
+		Date:  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+		Since: &since,
+		IDs:   []int{1, 2, 3},
+		Name:  "x",
+	}
+
+	params, err := EncodePath(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"id": "1", "date": "2023-01-02", "since": "2022-05-06", "ids": "1,2,3"}
+	if !reflect.DeepEqual(params, want) {
+		t.Errorf("got %v, want %v", params, want)
+	}
+}
+
+func TestEncodeOmitEmpty(t *testing.T) {
+	type t1 struct {
+		Q    string   `query:"q,omitempty" form:"q,omitempty"`
+		Page int      `query:"page,omitempty" form:"page"`
+		Tags []string `query:"tags,omitempty"`
+	}
+
+	vals, err := EncodeQuery(t1{Q: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (url.Values{"q": {"x"}}); !reflect.DeepEqual(vals, want) {
+		t.Errorf("got %v, want %v", vals, want)
+	}
+
+	vals, err = EncodeForm(t1{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (url.Values{"page": {"0"}}); !reflect.DeepEqual(vals, want) {
+		t.Errorf("got %v, want %v", vals, want)
+	}
+
+	// omitempty doesn't affect decoding
+	v := t1{}
+	if err := DecodeQuery(url.Values{"page": {"2"}}, &v); err != nil || v.Page != 2 {
+		t.Errorf("got %v and page %d, want page 2", err, v.Page)
+	}
+}
+
+func TestEncodeURL(t *testing.T) {
+	type t1 struct {
+		ID     string `path:"id"`
+		Active bool   `query:"active"`
+	}
+
+	u, err := EncodeURL("/users/{id}", t1{ID: "a/b", Active: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/a%2Fb?active=true"; u != want {
+		t.Errorf("got %q, want %q", u, want)
+	}
+
+	u, err = EncodeURL("/users/{id}?lang=en", &t1{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/1?lang=en&active=false"; u != want {
+		t.Errorf("got %q, want %q", u, want)
+	}
+
+	// placeholders that can't be filled aren't left in the URL
+	type t2 struct {
+		ID *int `path:"id"`
+	}
+	if u, err := EncodeURL("/users/{id}", t2{}); err == nil {
+		t.Errorf("got %q, want error", u)
+	}
+	if u, err := EncodeURL("/users/{user_id}", t1{ID: "1"}); err == nil {
+		t.Errorf("got %q, want error", u)
+	}
+	if r, err := EncodeRequest(http.MethodPost, "/users/{id}", t2{}); err == nil {
+		t.Errorf("got %v, want error", r.URL)
+	}
+
+	// http.ServeMux remainder wildcards keep their slashes and {$} is dropped
+	type t3 struct {
+		Path string `path:"path"`
+	}
+	u, err = EncodeURL("/files/{path...}", t3{Path: "a b/c.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/files/a%20b/c.txt"; u != want {
+		t.Errorf("got %q, want %q", u, want)
+	}
+	u, err = EncodeURL("/users/{id}/{$}", t1{ID: "1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/users/1/?active=false"; u != want {
+		t.Errorf("got %q, want %q", u, want)
+	}
+}
+
+func TestDecodeQueryMap(t *testing.T) {
+	vals := url.Values{"a": {"1", "2"}, "b": {"3"}}
+
+	m1 := map[string][]string{}
+	if err := DecodeQuery(vals, &m1); err != nil {
+		t.Error(err)
+	} else if want := map[string][]string{"a": {"1", "2"}, "b": {"3"}}; !reflect.DeepEqual(m1, want) {
+		t.Errorf("got %v, want %v", m1, want)
+	}
+
+	var m2 map[string]string
+	if err := DecodeQuery(vals, &m2); err != nil {
+		t.Error(err)
+	} else if want := map[string]string{"a": "1", "b": "3"}; !reflect.DeepEqual(m2, want) {
+		t.Errorf("got %v, want %v", m2, want)
+	}
+}
+
+func TestBodyContentEncoding(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	zw.Write([]byte(`{"name":"x"}`))
+	zw.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, "/", buf)
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Encoding", "gzip")
+
+	v := t1{}
+	if err := Body(r, &v); err != nil {
+		t.Error(err)
+	} else if v.Name != "x" {
+		t.Errorf("got %q, want %q", v.Name, "x")
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Encoding", "br")
+
+	err := Body(r, &v)
+	if !errors.Is(err, ErrUnsupportedContentEncoding) {
+		t.Errorf("got %v, want ErrUnsupportedContentEncoding", err)
+	}
+	if StatusCode(err) != http.StatusUnsupportedMediaType {
+		t.Errorf("got status %d, want 415", StatusCode(err))
+	}
+}
+
+func TestRegisterContentEncoding(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	// chained encodings are undone in reverse order
+	buf := &bytes.Buffer{}
+	zw := gzip.NewWriter(buf)
+	fw := base64.NewEncoder(base64.StdEncoding, zw)
+	fw.Write([]byte(`{"name":"x"}`))
+	fw.Close()
+	zw.Close()
+
+	b := New()
+	b.RegisterContentEncoding("B64", func(r io.Reader) (io.ReadCloser, error) {
+		return io.NopCloser(base64.NewDecoder(base64.StdEncoding, r)), nil
+	})
+
+	r, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader(buf.Bytes()))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Encoding", "b64, identity, GZIP")
+
+	v := t1{}
+	if err := b.Body(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "x" {
+		t.Errorf("got %q, want x", v.Name)
+	}
+
+	// support can be removed
+	b.RegisterContentEncoding("gzip", nil)
+	r, _ = http.NewRequest(http.MethodPost, "/", bytes.NewReader(buf.Bytes()))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Encoding", "b64, gzip")
+	var e *UnsupportedContentEncodingError
+	if err := b.Body(r, &v); !errors.As(err, &e) || e.Encoding != "gzip" {
+		t.Errorf("got %v, want unsupported gzip", err)
+	}
+	if _, ok := New().encodings["gzip"]; !ok {
+		t.Error("expected other binders to keep gzip")
+	}
+}
+
+func TestQueryTimeFormat(t *testing.T) {
+	type t1 struct {
+		From time.Time  `query:"from" format:"2006-01-02"`
+		To   *time.Time `query:"to" format:"02/01/2006"`
+		At   time.Time  `query:"at"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?from=2023-01-02&to=03/01/2023&at=2023-01-02T15:04:05Z", nil)
+
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC); !v.From.Equal(want) {
+		t.Errorf("got %v, want %v", v.From, want)
+	}
+	if want := time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC); v.To == nil || !v.To.Equal(want) {
+		t.Errorf("got %v, want %v", v.To, want)
+	}
+	if want := time.Date(2023, 1, 2, 15, 4, 5, 0, time.UTC); !v.At.Equal(want) {
+		t.Errorf("got %v, want %v", v.At, want)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?from=2023-01-02T15:04:05Z", nil)
+	if err := Query(r, &v); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestTrim(t *testing.T) {
+	type t1 struct {
+		Name  *string `query:"name"`
+		Email *string `query:"email"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?name=+x+&email=+", nil)
+
+	// empty values are kept
+	v1 := t1{}
+	if err := Query(r, &v1, Trim); err != nil {
+		t.Fatal(err)
+	}
+	if v1.Name == nil || *v1.Name != "x" {
+		t.Errorf("got %v, want %q", v1.Name, "x")
+	}
+	if v1.Email == nil || *v1.Email != "" {
+		t.Errorf("got %v, want %q", v1.Email, "")
+	}
+
+	// and deleted by Vacuum
+	v2 := t1{}
+	if err := Query(r, &v2, Vacuum); err != nil {
+		t.Fatal(err)
+	}
+	if v2.Email != nil {
+		t.Errorf("got %q, want nil", *v2.Email)
+	}
+}
+
+func TestBodyMultipart(t *testing.T) {
+	type t1 struct {
+		Name   string                  `form:"name"`
+		Avatar *multipart.FileHeader   `form:"avatar"`
+		Docs   []*multipart.FileHeader `form:"docs"`
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "x")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	v := t1{}
+	if err := Body(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := CleanupMultipart(r); err != nil {
+			t.Error(err)
+		}
+	}()
+	if v.Name != "x" {
+		t.Errorf("got %q, want %q", v.Name, "x")
+	}
+	if v.Avatar == nil {
+		t.Error("got nil, want file header")
+	} else if v.Avatar.Filename != "avatar.png" || v.Avatar.Size != 3 {
+		t.Errorf("got %s (%d), want avatar.png (3)", v.Avatar.Filename, v.Avatar.Size)
+	}
+	if v.Docs != nil {
+		t.Errorf("got %v, want nil", v.Docs)
+	}
+}
+
+func TestBodyMultipartRequiredFile(t *testing.T) {
+	type t1 struct {
+		Name   string                  `form:"name"`
+		Avatar *multipart.FileHeader   `form:"avatar" required:"true"`
+		Docs   []*multipart.FileHeader `form:"docs" required:"true"`
+	}
+
+	newRequest := func(files ...string) *http.Request {
+		body := &bytes.Buffer{}
+		mw := multipart.NewWriter(body)
+		mw.WriteField("name", "x")
+		for _, name := range files {
+			fw, _ := mw.CreateFormFile(name, name+".txt")
+			fw.Write([]byte("x"))
+		}
+		mw.Close()
+		r, _ := http.NewRequest(http.MethodPost, "/", body)
+		r.Header.Set("Content-Type", mw.FormDataContentType())
+		return r
+	}
+
+	r := newRequest("avatar", "docs")
+	defer CleanupMultipart(r)
+	v := t1{}
+	if err := Body(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Avatar == nil || len(v.Docs) != 1 {
+		t.Errorf("got %+v, want avatar and docs", v)
+	}
+
+	r = newRequest("avatar")
+	defer CleanupMultipart(r)
+	var e *MissingFieldError
+	if err := Body(r, &t1{}); !errors.As(err, &e) || e.Name != "docs" || e.Field != "Docs" {
+		t.Errorf("got %v, want missing docs", err)
+	}
+}
+
+func TestBodyMultipartValues(t *testing.T) {
+	type t1 struct {
+		Name  string                `form:"name"`
+		Tags  []string              `form:"tags"`
+		Count int                   `form:"count"`
+		Doc   *multipart.FileHeader `form:"doc"`
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "x")
+	mw.WriteField("tags", "a")
+	mw.WriteField("tags", "b")
+	fw, _ := mw.CreateFormFile("doc", "doc.txt")
+	fw.Write([]byte("hello"))
+	mw.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, "/?name=q&count=3", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	v := t1{}
+	if err := With(WithMaxMultipartMemory(1)).Body(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	defer CleanupMultipart(r)
+
+	if v.Name != "x" || !reflect.DeepEqual(v.Tags, []string{"a", "b"}) {
+		t.Errorf("got %+v, want name x and tags [a b]", v)
+	}
+	// query parameters aren't bound
+	if v.Count != 0 {
+		t.Errorf("got %d, want %d", v.Count, 0)
+	}
+	if v.Doc == nil || v.Doc.Filename != "doc.txt" {
+		t.Errorf("got %v, want doc.txt", v.Doc)
+	} else if f, err := v.Doc.Open(); err != nil {
+		t.Error(err)
+	} else {
+		b, _ := io.ReadAll(f)
+		f.Close()
+		if string(b) != "hello" {
+			t.Errorf("got %q, want %q", b, "hello")
+		}
+	}
+}
+
+func TestJSONDecode(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	var calls []string
+	JSONDecode = func(r io.Reader, v any, flags []Flag) error {
+		calls = append(calls, "json")
+		dec := json.NewDecoder(r)
+		if hasFlag(flags, Strict) {
+			dec.DisallowUnknownFields()
+		}
+		return dec.Decode(v)
+	}
+	XMLDecode = func(r io.Reader, v any, flags []Flag) error {
+		calls = append(calls, "xml")
+		return xml.NewDecoder(r).Decode(v)
+	}
+	defer func() {
+		JSONDecode = nil
+		XMLDecode = nil
+	}()
+
+	v := t1{}
+	if err := DecodeBody(strings.NewReader(`{"name":"x"}`), "application/json", &v); err != nil {
+		t.Fatal(err)
+	}
+	if err := DecodeBody(strings.NewReader(`<t1><name>y</name></t1>`), "application/xml", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "y" || !reflect.DeepEqual(calls, []string{"json", "xml"}) {
+		t.Errorf("got %+v and calls %v", v, calls)
+	}
+
+	// the flags are passed to the hook
+	if err := DecodeBody(strings.NewReader(`{"name":"x","age":1}`), "application/json", &v, Strict); err == nil {
+		t.Error("got nil, want error")
+	}
+
+	// a new Binder doesn't use the package level hooks
+	calls = nil
+	if err := New().DecodeBody(strings.NewReader(`{"name":"z"}`), "application/json", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "z" || calls != nil {
+		t.Errorf("got %+v and calls %v", v, calls)
+	}
+}
+
+func TestBodyFormIgnoresQuery(t *testing.T) {
+	type t1 struct {
+		Name  string `form:"name"`
+		Admin bool   `form:"admin"`
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/?name=q&admin=true", strings.NewReader("name=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	v := t1{}
+	if err := Body(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (t1{Name: "x"}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestFormJSONOption(t *testing.T) {
+	type meta struct {
+		Title string `json:"title"`
+		Pages int    `json:"pages"`
+	}
+	type t1 struct {
+		Meta  meta              `form:"meta,json"`
+		Raw   json.RawMessage   `form:"raw,json"`
+		Attrs map[string]string `form:"attrs,json"`
+		IDs   []int             `form:"ids,json"`
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("meta", `{"title":"x","pages":3}`)
+	mw.WriteField("raw", `{"a":[1,2]}`)
+	mw.WriteField("attrs", `{"k":"v"}`)
+	mw.WriteField("ids", `[1,2]`)
+	mw.Close()
+
+	r, _ := http.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	v := t1{}
+	if err := Body(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	defer CleanupMultipart(r)
+
+	if v.Meta != (meta{Title: "x", Pages: 3}) {
+		t.Errorf("got %+v", v.Meta)
+	}
+	if string(v.Raw) != `{"a":[1,2]}` {
+		t.Errorf("got %s", v.Raw)
+	}
+	if v.Attrs["k"] != "v" || !reflect.DeepEqual(v.IDs, []int{1, 2}) {
+		t.Errorf("got %+v", v)
+	}
+
+	err := DecodeForm(url.Values{"meta": {"{"}}, &v)
+	var errs form.DecodeErrors
+	if !errors.As(err, &errs) || errs["meta"] == nil {
+		t.Errorf("got %v, want decode error for meta", err)
+	}
+}
+
+type slowReader struct {
+	delay time.Duration
+	r     io.Reader
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(s.delay)
+	return s.r.Read(p)
+}
+
+// blockingReader blocks until done is closed.
+type blockingReader struct {
+	done chan struct{}
+}
+
+func (b *blockingReader) Read(p []byte) (int, error) {
+	<-b.done
+	return 0, io.EOF
+}
+
+func TestBodyTimeout(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	newRequest := func() *http.Request {
+		body := &slowReader{delay: 50 * time.Millisecond, r: strings.NewReader(`{"name":"x"}`)}
+		r, _ := http.NewRequest(http.MethodPost, "/", body)
+		r.Header.Set("Content-Type", "application/json")
+		return r
+	}
+
+	v := t1{}
+	err := With(WithBodyTimeout(10*time.Millisecond)).Body(newRequest(), &v)
+	if !errors.Is(err, ErrBodyTimeout) {
+		t.Fatalf("got %v, want ErrBodyTimeout", err)
+	}
+	if StatusCode(err) != http.StatusRequestTimeout {
+		t.Errorf("got status %d, want 408", StatusCode(err))
+	}
+
+	v = t1{}
+	if err := With(WithBodyTimeout(time.Second)).Body(newRequest(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "x" {
+		t.Errorf("got %q, want x", v.Name)
+	}
+
+	// the first read of a body of unknown length is timed too
+	body := &blockingReader{done: make(chan struct{})}
+	defer close(body.done)
+	r, _ := http.NewRequest(http.MethodPost, "/", body)
+	r.Header.Set("Content-Type", "application/json")
+	start := time.Now()
+	if err := With(WithBodyTimeout(10*time.Millisecond)).Body(r, &t1{}); !errors.Is(err, ErrBodyTimeout) {
+		t.Errorf("got %v, want ErrBodyTimeout", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("took %s, want the timeout", d)
+	}
+}
+
+func TestRewindBody(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name" form:"name"`
+	}
+
+	for _, ct := range []string{"application/json", "application/x-www-form-urlencoded"} {
+		body := `{"name":"x"}`
+		if ct != "application/json" {
+			body = "name=x"
+		}
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", ct)
+
+		v := t1{}
+		if err := Body(r, &v, RewindBody); err != nil {
+			t.Fatal(err)
+		}
+		if v.Name != "x" {
+			t.Errorf("%s: got %q, want x", ct, v.Name)
+		}
+		b, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != body {
+			t.Errorf("%s: got %q, want %q", ct, b, body)
+		}
+	}
+
+	// a compressed body is replaced by its decompressed content
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"name":"x"}`))
+	zw.Close()
+	r, _ := http.NewRequest(http.MethodPost, "/", bytes.NewReader(gz.Bytes()))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("Content-Length", strconv.Itoa(gz.Len()))
+
+	if err := Body(r, &t1{}, RewindBody); err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != `{"name":"x"}` {
+		t.Errorf("got %q, want %q", b, `{"name":"x"}`)
+	}
+	if ce := r.Header.Get("Content-Encoding"); ce != "" {
+		t.Errorf("got Content-Encoding %q, want none", ce)
+	}
+	if r.ContentLength != int64(len(b)) || r.Header.Get("Content-Length") != strconv.Itoa(len(b)) {
+		t.Errorf("got Content-Length %d (%q), want %d", r.ContentLength, r.Header.Get("Content-Length"), len(b))
+	}
+}
+
+func TestBodyChunked(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader(`{"name":"x"}`)))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", "application/json")
+
+	v := t1{}
+	if err := Body(r, &v); err != nil {
+		t.Error(err)
+	} else if v.Name != "x" {
+		t.Errorf("got %q, want %q", v.Name, "x")
+	}
+
+	// empty body of unknown length
+	r, _ = http.NewRequest(http.MethodPost, "/", io.NopCloser(strings.NewReader("")))
+	r.ContentLength = -1
+	r.Header.Set("Content-Type", "application/json")
+
+	if err := Body(r, &v); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPathValueFuncNotSet(t *testing.T) {
+	defer SetPathValueFunc(nil)()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	if err := Path(r, &struct{}{}); !errors.Is(err, ErrPathValueFuncNotSet) {
+		t.Errorf("got %v, want ErrPathValueFuncNotSet", err)
+	}
+}
+
+func TestSetPathValueFunc(t *testing.T) {
+	defer SetPathValueFunc(nil)()
+
+	fn := func(r *http.Request, k string) string { return "1" }
+	restore := SetPathValueFunc(fn)
+	if PathValueFunc == nil {
+		t.Fatal("expected PathValueFunc to be set")
+	}
+	func() {
+		defer SetPathValueFunc(func(r *http.Request, k string) string { return "2" })()
+		if got := PathValue(&http.Request{}, "id"); got != "2" {
+			t.Errorf("got %q, want %q", got, "2")
+		}
+	}()
+	if got := PathValue(&http.Request{}, "id"); got != "1" {
+		t.Errorf("got %q, want %q", got, "1")
+	}
+	restore()
+	if PathValueFunc != nil {
+		t.Error("expected PathValueFunc to be restored")
+	}
+
+	SetPathValueFunc(fn)
+	ResetPathValueFunc()
+	if reflect.ValueOf(PathValueFunc).Pointer() != reflect.ValueOf(defaultPathValueFunc).Pointer() {
+		t.Error("expected PathValueFunc to be reset")
+	}
+}
+
+func TestPathComplex(t *testing.T) {
+	type t1 struct {
+		C complex128 `path:"c"`
+		P uintptr    `path:"p"`
+	}
+
+	type t2 struct {
+		C complex128 `path:"c"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		switch k {
+		case "c":
+			return "1+2i"
+		case "p":
+			return "1"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	v2 := t2{}
+	if err := Path(r, &v2); err != nil {
+		t.Error(err)
+	} else if v2.C != complex(1, 2) {
+		t.Errorf("got %v, want %v", v2.C, complex(1, 2))
+	}
+
+	// uintptr is not supported
+	if err := Path(r, &t1{}); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+type testValidatable struct {
+	Name      string `query:"name"`
+	Limit     int    `query:"limit"`
+	validated bool
+}
+
+func (v *testValidatable) Validate() error {
+	v.validated = true
+	if v.Name == "" {
+		return errors.New("name can't be empty")
+	}
+	return nil
+}
+
+func TestRequestValidate(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodGet, "/?name=x", nil)
+	v := testValidatable{}
+	if err := Request(r, &v); err != nil {
+		t.Error(err)
+	} else if !v.validated {
+		t.Error("Validate wasn't called")
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/", nil)
+	if err := Request(r, &testValidatable{}); err == nil {
+		t.Error("got nil, want error")
+	}
+
+	// Validate isn't called if binding fails
+	r, _ = http.NewRequest(http.MethodGet, "/?name=x&limit=x", nil)
+	v = testValidatable{}
+	if err := Request(r, &v); err == nil {
+		t.Error("got nil, want error")
+	} else if v.validated {
+		t.Error("Validate was called")
+	}
+}
+
+func TestRequestSlice(t *testing.T) {
+	type item struct {
+		Name string `json:"name"`
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/?name=x", strings.NewReader(`[{"name":"a"},{"name":"b"}]`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Token", "x")
+
+	var v []item
+	if err := Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := []item{{"a"}, {"b"}}; !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestRequestNonStruct(t *testing.T) {
+	r, _ := http.NewRequest(http.MethodPost, "/?a=2", strings.NewReader(`{"a":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("X-Token", "x")
+
+	var v any
+	if err := Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]any{"a": float64(1)}; !reflect.DeepEqual(v, want) {
+		t.Errorf("got %v, want %v", v, want)
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "/?a=2", strings.NewReader(`5`))
+	r.Header.Set("Content-Type", "application/json")
+
+	var i int
+	if err := Request(r, &i); err != nil {
+		t.Fatal(err)
+	}
+	if i != 5 {
+		t.Errorf("got %d, want 5", i)
+	}
+}
+
+func TestUnixTime(t *testing.T) {
+	type t1 struct {
+		TS      time.Time   `query:"ts" format:"unix"`
+		TSMilli *time.Time  `query:"ts_milli" format:"unixmilli"`
+		Times   []time.Time `query:"times" format:"unix"`
+		Path    time.Time   `path:"since" format:"unixmilli"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "1700000000123"
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?ts=1700000000&ts_milli=1700000000123&times=0&times=1", nil)
+
+	v := t1{}
+	if err := Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Unix(1700000000, 0); !v.TS.Equal(want) {
+		t.Errorf("got %v, want %v", v.TS, want)
+	}
+	if want := time.UnixMilli(1700000000123); v.TSMilli == nil || !v.TSMilli.Equal(want) || !v.Path.Equal(want) {
+		t.Errorf("got %v and %v, want %v", v.TSMilli, v.Path, want)
+	}
+	if len(v.Times) != 2 || v.Times[1].Unix() != 1 {
+		t.Errorf("got %v", v.Times)
+	}
+
+	params, err := EncodePath(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params["since"] != "1700000000123" {
+		t.Errorf("got %q, want 1700000000123", params["since"])
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?ts=2024-01-01", nil)
+	if err := Query(r, &t1{}); err == nil {
+		t.Error("expected error for non numeric unix time")
+	}
+}
+
+func TestSQLNullTypes(t *testing.T) {
+	type t1 struct {
+		Name    sql.NullString  `query:"name"`
+		Count   sql.NullInt64   `query:"count"`
+		Active  sql.NullBool    `query:"active"`
+		Day     sql.NullTime    `query:"day" format:"2006-01-02"`
+		Missing sql.NullInt64   `query:"missing"`
+		Score   sql.NullFloat64 `path:"score"`
+		Since   sql.NullTime    `path:"since"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		switch k {
+		case "score":
+			return "1.5"
+		case "since":
+			return "2024-03-01T10:00:00Z"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?name=x&count=3&active=true&day=2024-03-02", nil)
+
+	v := t1{}
+	if err := Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != (sql.NullString{String: "x", Valid: true}) {
+		t.Errorf("got %+v", v.Name)
+	}
+	if v.Count != (sql.NullInt64{Int64: 3, Valid: true}) {
+		t.Errorf("got %+v", v.Count)
+	}
+	if v.Active != (sql.NullBool{Bool: true, Valid: true}) {
+		t.Errorf("got %+v", v.Active)
+	}
+	if !v.Day.Valid || !v.Day.Time.Equal(time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v", v.Day)
+	}
+	// absent values leave Valid false
+	if v.Missing.Valid {
+		t.Errorf("got %+v, want invalid", v.Missing)
+	}
+	if v.Score != (sql.NullFloat64{Float64: 1.5, Valid: true}) {
+		t.Errorf("got %+v", v.Score)
+	}
+	if !v.Since.Valid || !v.Since.Time.Equal(time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %+v", v.Since)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?count=x", nil)
+	if err := Query(r, &t1{}); err == nil {
+		t.Error("expected error for invalid NullInt64")
+	}
+}
+
+func TestQueryDelim(t *testing.T) {
+	type t1 struct {
+		IDs  []int    `query:"ids" delim:","`
+		Tags []string `query:"tags" delim:"|"`
+		Keep []string `query:"keep"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?ids=1,2,3&ids=4&tags=a|b&keep=x,y", nil)
+
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	want := t1{
+		IDs:  []int{1, 2, 3, 4},
+		Tags: []string{"a", "b"},
+		Keep: []string{"x,y"},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	// repeated keys still work
+	r, _ = http.NewRequest(http.MethodGet, "/?ids=1&ids=2", nil)
+	v = t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.IDs, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", v.IDs)
+	}
+
+	// defaults are split like sent values
+	type t2 struct {
+		IDs []int `query:"ids" path:"ids" delim:"," default:"1,2"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return ""
+	})()
+
+	r, _ = http.NewRequest(http.MethodGet, "/", nil)
+	v2 := t2{}
+	if err := Query(r, &v2); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v2.IDs, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", v2.IDs)
+	}
+	v2 = t2{}
+	if err := Path(r, &v2); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(v2.IDs, []int{1, 2}) {
+		t.Errorf("got %v, want [1 2]", v2.IDs)
+	}
+}
+
+func TestQuerySemicolonSeparator(t *testing.T) {
+	type t1 struct {
+		A int    `query:"a"`
+		B string `query:"b"`
+		C string `query:"c"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?a=1;b=x+y&c=%3B", nil)
+
+	v := t1{}
+	if err := Query(r, &v, SemicolonSeparator); err != nil {
+		t.Fatal(err)
+	}
+	if want := (t1{A: 1, B: "x y", C: ";"}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	// pairs with a semicolon are dropped by default
+	v = t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (t1{C: ";"}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestQueryNested(t *testing.T) {
+	type page struct {
+		Size int `query:"size" default:"20"`
+	}
+	type filter struct {
+		Name string   `query:"name" required:"true"`
+		Tags []string `query:"tags"`
+		Page *page    `query:"page"`
+	}
+	type t1 struct {
+		Filter filter `query:"filter"`
+		Sort   string `query:"sort"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?filter.name=x&filter.tags=a&filter.tags=b&sort=name", nil)
+
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	want := t1{
+		Filter: filter{Name: "x", Tags: []string{"a", "b"}, Page: &page{Size: 20}},
+		Sort:   "name",
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	// nested keys are checked like top level ones
+	r, _ = http.NewRequest(http.MethodGet, "/?filter.tags=a", nil)
+	var e *MissingFieldError
+	if err := Query(r, &t1{}); !errors.As(err, &e) || e.Name != "filter.name" || e.Field != "Filter.Name" {
+		t.Errorf("got %v, want missing filter.name", err)
+	}
+}
+
+func TestQueryTaggedEmbedded(t *testing.T) {
+	type Page struct {
+		Sort string `query:"sort" required:"true"`
+		Size int    `query:"size" default:"20"`
+	}
+	type t1 struct {
+		Page `query:"pg"`
+		Q    string `query:"q"`
+	}
+
+	// like the form decoder, both the prefixed and the own keys are accepted
+	for _, query := range []string{"pg.sort=a", "sort=a"} {
+		r, _ := http.NewRequest(http.MethodGet, "/?"+query, nil)
+		v := t1{}
+		if err := Query(r, &v); err != nil {
+			t.Fatalf("%s: %v", query, err)
+		}
+		if v.Sort != "a" || v.Size != 20 {
+			t.Errorf("%s: got %+v, want sort a and size 20", query, v)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?q=x", nil)
+	var e *MissingFieldError
+	if err := Query(r, &t1{}); !errors.As(err, &e) || e.Name != "pg.sort" {
+		t.Errorf("got %v, want missing pg.sort", err)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?pg.sort=a&pg.size=5&other=1", nil)
+	leftover, err := QueryLeftover(r, &t1{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (url.Values{"other": {"1"}}); !reflect.DeepEqual(leftover, want) {
+		t.Errorf("got %v, want %v", leftover, want)
+	}
+}
+
+func TestQueryRecursive(t *testing.T) {
+	type cat struct {
+		Name   string `query:"name"`
+		Parent *cat   `query:"parent"`
+	}
+
+	v := cat{}
+	if err := DecodeQuery(url.Values{"name": {"x"}, "parent.name": {"y"}}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "x" || v.Parent == nil || v.Parent.Name != "y" {
+		t.Errorf("got %+v, want x with parent y", v)
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?name=x&parent.name=y", nil)
+	v = cat{}
+	if err := Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "x" || v.Parent == nil || v.Parent.Name != "y" {
+		t.Errorf("got %+v, want x with parent y", v)
+	}
+}
+
+func TestQueryIndexedKeys(t *testing.T) {
+	type t1 struct {
+		Tags  []string `query:"tags"`
+		IDs   []int    `query:"ids"`
+		Pair  [2]int   `query:"pair"`
+		Other string   `query:"other"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?tags[1]=b&tags[0]=a&tags[2]=c&ids[3]=4&ids[0]=1&pair[1]=2&other=x", nil)
+
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.Tags, []string{"a", "b", "c"}) {
+		t.Errorf("got %v, want [a b c]", v.Tags)
+	}
+	// gaps are filled with zero values
+	if !reflect.DeepEqual(v.IDs, []int{1, 0, 0, 4}) {
+		t.Errorf("got %v, want [1 0 0 4]", v.IDs)
+	}
+	if v.Pair != [2]int{0, 2} {
+		t.Errorf("got %v, want [0 2]", v.Pair)
+	}
+	if v.Other != "x" {
+		t.Errorf("got %q, want x", v.Other)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?tags[100000]=a", nil)
+	if err := Query(r, &v); err == nil {
+		t.Error("expected error for huge index")
+	}
+}
+
+func TestQueryRaw(t *testing.T) {
+	type t1 struct {
+		B   string `query:"b"`
+		A   string `query:"a"`
+		Raw string `query:",raw"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?b=+2+&a=1&sig=x%20y", nil)
+
+	v := t1{}
+	if err := Query(r, &v, Vacuum); err != nil {
+		t.Fatal(err)
+	}
+	if v.B != "2" || v.A != "1" {
+		t.Errorf("got %+v, want B:2 A:1", v)
+	}
+	if v.Raw != r.URL.RawQuery {
+		t.Errorf("got %q, want %q", v.Raw, r.URL.RawQuery)
+	}
+}
+
+func TestBind(t *testing.T) {
+	type t1 struct {
+		Page int      `query:"page"`
+		Tags []string `query:"tags"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=2&tags=a&tags=b", nil)
+
+	v, err := Bind[t1](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (&t1{Page: 2, Tags: []string{"a", "b"}}); !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?page=x", nil)
+	if v, err := Bind[t1](r); err == nil || v != nil {
+		t.Errorf("got %v, %v, want nil and error", v, err)
+	}
+}
+
+func TestLiteralPlus(t *testing.T) {
+	type t1 struct {
+		Q string `query:"q" form:"q"`
+	}
+
+	tests := []struct {
+		flags []Flag
+		want  string
+	}{
+		{nil, "a b%"},
+		{[]Flag{LiteralPlus}, "a+b%"},
+	}
+
+	for _, test := range tests {
+		// query string and form body behave the same
+		r, _ := http.NewRequest(http.MethodGet, "/?q=a+b%25", nil)
+		v := t1{}
+		if err := Query(r, &v, test.flags...); err != nil {
+			t.Fatal(err)
+		} else if v.Q != test.want {
+			t.Errorf("query: got %q, want %q", v.Q, test.want)
+		}
+
+		r, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader("q=a+b%25"))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		v = t1{}
+		if err := Body(r, &v, test.flags...); err != nil {
+			t.Fatal(err)
+		} else if v.Q != test.want {
+			t.Errorf("form: got %q, want %q", v.Q, test.want)
+		}
+	}
+}
+
+func TestQueryLeftover(t *testing.T) {
+	type t1 struct {
+		Page int      `query:"page"`
+		Tags []string `query:"tags"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=2&tags[0]=a&utm_source=x&Page=3", nil)
+
+	v := t1{}
+	rest, err := QueryLeftover(r, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Page != 2 {
+		t.Errorf("got %d, want %d", v.Page, 2)
+	}
+	want := url.Values{"utm_source": {"x"}, "Page": {"3"}}
+	if !reflect.DeepEqual(rest, want) {
+		t.Errorf("got %v, want %v", rest, want)
+	}
+
+	rest, err = QueryLeftover(r, &t1{}, CaseInsensitive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = url.Values{"utm_source": {"x"}}
+	if !reflect.DeepEqual(rest, want) {
+		t.Errorf("got %v, want %v", rest, want)
+	}
+
+	type t2 struct {
+		Email string `query:"email,alias=e-mail"`
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?e-mail=x&E-Mail=y&utm_source=x", nil)
+	v2 := t2{}
+	rest, err = QueryLeftover(r, &v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = url.Values{"E-Mail": {"y"}, "utm_source": {"x"}}
+	if v2.Email != "x" || !reflect.DeepEqual(rest, want) {
+		t.Errorf("got %q and %v, want %q and %v", v2.Email, rest, "x", want)
+	}
+
+	rest, err = QueryLeftover(r, &t2{}, CaseInsensitive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = url.Values{"utm_source": {"x"}}
+	if !reflect.DeepEqual(rest, want) {
+		t.Errorf("got %v, want %v", rest, want)
+	}
+}
+
+func TestPathEmbedded(t *testing.T) {
+	type L3 struct {
+		ID int `path:"id"`
+	}
+
+	type L2 struct {
+		*L3
+	}
+
+	type L1 struct {
+		L2
+	}
+
+	type t1 struct {
+		*L1
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		if k == "id" {
+			return "123"
+		}
+		return ""
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/", nil)
+
+	// intermediate nil pointers are allocated
+	v := t1{}
+	if err := Path(r, &v); err != nil {
+		t.Error(err)
+	} else if v.L1 == nil || v.L3 == nil {
+		t.Error("got nil, want allocated embedded structs")
+	} else if v.ID != 123 {
+		t.Errorf("got %d, want %d", v.ID, 123)
+	}
+}
+
+func TestRequestSelfEmbedding(t *testing.T) {
+	type node struct {
+		*node
+		ID   int    `path:"id"`
+		Name string `json:"name"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "1"
+	})()
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	v := node{}
+	if err := Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 1 || v.Name != "x" {
+		t.Errorf("got %+v, want id 1 and name x", v)
+	}
+}
+
+func TestDecodeBody(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name" xml:"name" form:"name"`
+	}
+
+	tests := []struct {
+		contentType string
+		body        string
+	}{
+		{"application/json", `{"name":"x"}`},
+		{"application/xml", `<t1><name>x</name></t1>`},
+		{"application/x-www-form-urlencoded", `name=x`},
+	}
+
+	for _, test := range tests {
+		v := t1{}
+		if err := DecodeBody(strings.NewReader(test.body), test.contentType, &v); err != nil {
+			t.Error(err)
+		} else if v.Name != "x" {
+			t.Errorf("%s: got %q, want %q", test.contentType, v.Name, "x")
+		}
+	}
+}
+
+func TestBodyUnsupportedMediaType(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	newRequest := func(ct string) *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("x"))
+		if ct != "" {
+			r.Header.Set("Content-Type", ct)
+		}
+		return r
+	}
+
+	if err := Body(newRequest("application/cbor"), &t1{}); !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Errorf("got %v, want ErrUnsupportedMediaType", err)
+	}
+	if err := Body(newRequest("application/cbor"), &t1{}, IgnoreUnsupportedMediaType); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+	if err := Body(newRequest(""), &t1{}); err != nil {
+		t.Errorf("got %v, want nil", err)
+	}
+}
+
+func TestCaseInsensitive(t *testing.T) {
+	type t1 struct {
+		Page int      `query:"page"`
+		Tags []string `query:"tags"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?Page=2&TAGS=a&tags=b", nil)
+
+	v1 := t1{}
+	if err := Query(r, &v1); err != nil {
+		t.Fatal(err)
+	}
+	if v1.Page != 0 || len(v1.Tags) != 1 {
+		t.Errorf("got %+v, want {Page:0 Tags:[b]}", v1)
+	}
+
+	v2 := t1{}
+	if err := Query(r, &v2, CaseInsensitive); err != nil {
+		t.Fatal(err)
+	}
+	if v2.Page != 2 || len(v2.Tags) != 2 {
+		t.Errorf("got %+v, want {Page:2 Tags:[a b]}", v2)
+	}
+}
+
+func TestBool(t *testing.T) {
+	type t1 struct {
+		A bool `path:"a" query:"a"`
+		B bool `path:"b" query:"b"`
+		C bool `path:"c" query:"c"`
+	}
+
+	vals := map[string]string{"a": "on", "b": "yes", "c": "off"}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return vals[k]
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?a=on&b=yes&c=off", nil)
+
+	v1 := t1{C: true}
+	if err := Path(r, &v1); err != nil {
+		t.Error(err)
+	} else if !v1.A || !v1.B || v1.C {
+		t.Errorf("got %+v, want {A:true B:true C:false}", v1)
+	}
+
+	v2 := t1{C: true}
+	if err := Query(r, &v2); err != nil {
+		t.Error(err)
+	} else if !v2.A || !v2.B || v2.C {
+		t.Errorf("got %+v, want {A:true B:true C:false}", v2)
+	}
+}
+
+func TestEmptyBoolTrue(t *testing.T) {
+	type t1 struct {
+		Verbose bool   `query:"verbose"`
+		Debug   *bool  `query:"debug"`
+		Quiet   bool   `query:"quiet"`
+		Off     bool   `query:"off"`
+		Name    string `query:"name"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?verbose&debug=&off=false&name&limit=10", nil)
+
+	v := t1{}
+	if err := Query(r, &v, EmptyBoolTrue); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Verbose || v.Debug == nil || !*v.Debug {
+		t.Errorf("got %+v, want present bools set to true", v)
+	}
+	if v.Quiet || v.Off || v.Name != "" {
+		t.Errorf("got %+v, want absent and false bools untouched", v)
+	}
+
+	// present keys are set before Vacuum drops empty values
+	v = t1{}
+	if err := Query(r, &v, EmptyBoolTrue, Vacuum); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Verbose {
+		t.Error("expected verbose to be true with Vacuum")
+	}
+
+	v = t1{}
+	if err := DecodeQuery(url.Values{"VERBOSE": {""}}, &v, EmptyBoolTrue, CaseInsensitive); err != nil {
+		t.Fatal(err)
+	}
+	if !v.Verbose {
+		t.Error("expected verbose to be true with CaseInsensitive")
+	}
+
+	type t2 struct {
+		Verbose bool `query:"verbose,alias=v" param:"verbose,alias=v"`
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?v", nil)
+	v2 := t2{}
+	if err := Query(r, &v2, EmptyBoolTrue); err != nil {
+		t.Fatal(err)
+	}
+	if !v2.Verbose {
+		t.Error("expected verbose to be true for its alias")
+	}
+
+	v2 = t2{}
+	if err := DecodeValues(url.Values{"v": {""}}, "param", &v2, EmptyBoolTrue); err != nil {
+		t.Fatal(err)
+	}
+	if !v2.Verbose {
+		t.Error("expected verbose to be true with DecodeValues")
+	}
+
+	v = t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Verbose {
+		t.Error("expected verbose to be false without the flag")
+	}
+}
+
+func TestBodyStream(t *testing.T) {
+	type t1 struct {
+		ID int `json:"id"`
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"id":1},{"id":2},{"id":3}]`))
+	r.Header.Set("Content-Type", "application/json")
+
+	var ids []int
+	err := BodyStream(r, func(decode func(any) error) error {
+		v := t1{}
+		if err := decode(&v); err != nil {
+			return err
+		}
+		ids = append(ids, v.ID)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int{1, 2, 3}) {
+		t.Errorf("got %v, want %v", ids, []int{1, 2, 3})
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1}`))
+	r.Header.Set("Content-Type", "application/json")
+	if err := BodyStream(r, func(decode func(any) error) error { return nil }); err == nil {
+		t.Error("expected error for non array body")
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader(`<ids/>`))
+	r.Header.Set("Content-Type", "application/xml")
+	if err := BodyStream(r, func(decode func(any) error) error { return nil }); err == nil {
+		t.Error("expected error for xml body")
+	}
+}
+
+type testColor int
+
+const (
+	testRed testColor = iota + 1
+	testGreen
+)
+
+func (c *testColor) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	switch s {
+	case "red":
+		*c = testRed
+	case "green":
+		*c = testGreen
+	default:
+		return fmt.Errorf("invalid color %q", s)
+	}
+	return nil
+}
+
+func TestJSONUnmarshaler(t *testing.T) {
+	type t1 struct {
+		Color  testColor  `query:"color" path:"color"`
+		Accent *testColor `query:"accent"`
+		Page   int        `query:"page"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "green"
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?color=red&accent=green&page=2", nil)
+
+	v := t1{}
+	if err := Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Color != testRed || v.Accent == nil || *v.Accent != testGreen || v.Page != 2 {
+		t.Errorf("got %+v, want red, green and page 2", v)
+	}
+
+	v = t1{}
+	if err := Path(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Color != testGreen {
+		t.Errorf("got %v, want %v", v.Color, testGreen)
+	}
+
+	r, _ = http.NewRequest(http.MethodGet, "/?color=blue", nil)
+	if err := Query(r, &t1{}); err == nil {
+		t.Error("expected error")
+	}
+
+	// a pointer to a nil struct pointer is allocated, as by the form decoder
+	var p *t1
+	if err := DecodeQuery(url.Values{"color": {"red"}}, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p == nil || p.Color != testRed {
+		t.Errorf("got %+v, want red", p)
+	}
+}
+
+func TestAliases(t *testing.T) {
+	type t1 struct {
+		Email  string   `form:"email,alias=e-mail,alias=mail" header:"x-email,alias=x-mail"`
+		Tags   []string `form:"tags,alias=tag"`
+		Ignore string   `form:"ignore"`
+	}
+
+	for _, vals := range []url.Values{
+		{"email": {"a@b.c"}},
+		{"e-mail": {"a@b.c"}},
+		{"mail": {"a@b.c"}},
+	} {
+		v := t1{}
+		if err := DecodeForm(vals, &v); err != nil {
+			t.Fatal(err)
+		}
+		if v.Email != "a@b.c" {
+			t.Errorf("%v: got %q", vals, v.Email)
+		}
+	}
+
+	v := t1{}
+	if err := DecodeForm(url.Values{"tags": {"a"}, "tag": {"b"}}, &v); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(v.Tags)
+	if !reflect.DeepEqual(v.Tags, []string{"a", "b"}) {
+		t.Errorf("got %v", v.Tags)
+	}
+
+	v = t1{}
+	if err := DecodeForm(url.Values{"E-MAIL": {"a@b.c"}}, &v, CaseInsensitive); err != nil {
+		t.Fatal(err)
+	}
+	if v.Email != "a@b.c" {
+		t.Errorf("got %q", v.Email)
+	}
+
+	v = t1{}
+	h := http.Header{}
+	h.Set("X-Mail", "a@b.c")
+	if err := DecodeHeader(h, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Email != "a@b.c" {
+		t.Errorf("got %q", v.Email)
+	}
+}
@@ -0,0 +1,1314 @@
+package bind
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/go-playground/form/v4"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// DefaultMaxBodyBytes is the body size limit of a new Binder.
+	DefaultMaxBodyBytes = 10 << 20
+	// DefaultMaxMultipartMemory is the multipart memory limit of a new Binder.
+	DefaultMaxMultipartMemory = 32 << 20
+	// DefaultMaxArraySize is the largest slice or array index that a new
+	// Binder accepts in keys like tags[9999].
+	DefaultMaxArraySize = 10000
+)
+
+// Binder holds its own decoders, encoders and settings. The package level
+// functions use a default Binder that is configured through package level
+// variables like PathValueFunc, ValidateFunc and MaxBodyBytes.
+//
+// A Binder is safe for concurrent use once it's configured.
+type Binder struct {
+	// PathValueFunc is used to look up router path variables. It defaults to
+	// http.Request.PathValue when built with Go 1.22 or later.
+	PathValueFunc func(*http.Request, string) string
+	// PathValueFuncOk is like PathValueFunc but also reports whether the
+	// param is present in the route. When both are set PathValueFuncOk takes
+	// precedence and PathValueFunc is ignored. Absent params fall back to
+	// their default tag and are otherwise left untouched instead of being
+	// bound as an empty string. It's also needed by the StrictPath flag.
+	PathValueFuncOk func(*http.Request, string) (string, bool)
+	// MaxBodyBytes limits the size of request bodies read by Body. A value <=
+	// 0 disables the limit.
+	MaxBodyBytes int64
+	// MaxMultipartMemory is the maximum number of bytes of a multipart body
+	// that is stored in memory, the remainder is stored on disk in temporary
+	// files.
+	MaxMultipartMemory int64
+	// BodyTimeout limits the time Body takes to read the request body, e.g. to
+	// protect against slow clients. When it's exceeded, ErrBodyTimeout is
+	// returned. The body read is also aborted when the request context is
+	// done. A value <= 0 disables the timeout.
+	BodyTimeout time.Duration
+	// ValidateFunc is called by Request after all sources are bound
+	// successfully. Errors are wrapped in a *ValidationError. The Struct method
+	// of a go-playground/validator Validate can be used as is.
+	ValidateFunc func(any) error
+	// OnError, if set, is called by Request for every binding or validation
+	// error, e.g. to collect metrics. source is the source that failed, as in
+	// SourceError, or "validation".
+	OnError func(r *http.Request, source string, err error)
+	// JSONDecode, if set, replaces encoding/json for JSON bodies read by Body
+	// and DecodeBody, e.g. to use a faster library. flags holds the flags of
+	// the call, including Flags, so that the function can honor Strict and
+	// UseNumber. BodyStream always uses encoding/json.
+	JSONDecode func(r io.Reader, v any, flags []Flag) error
+	// XMLDecode, if set, replaces encoding/xml for XML bodies, see
+	// JSONDecode.
+	XMLDecode func(r io.Reader, v any, flags []Flag) error
+	// Flags are applied to every call in addition to the flags passed to the
+	// call.
+	Flags []Flag
+
+	queryDecoder  *valuesDecoder
+	formDecoder   *valuesDecoder
+	headerDecoder *valuesDecoder
+	cookieDecoder *valuesDecoder
+	// tagDecoders holds the decoders of DecodeValues for other tag names
+	tagDecoders *sync.Map // map[string]*valuesDecoder
+
+	queryEncoder  *form.Encoder
+	formEncoder   *form.Encoder
+	headerEncoder *form.Encoder
+	cookieEncoder *form.Encoder
+
+	mode       form.Mode
+	ns         namespace
+	types      map[reflect.Type]TypeFunc
+	interfaces map[reflect.Type]InterfaceFunc
+	bodies     []bodyDecoder
+	encodings  map[string]ContentEncodingFunc
+	// contextKeys maps ctx tag names to context keys
+	contextKeys map[string]any
+	// separator is the default delimiter of path slice values
+	separator string
+	// timeFormat is the default layout of time values
+	timeFormat string
+	// sources are the sources bound by Request, 0 means all
+	sources Source
+}
+
+// TypeFunc converts a string value to a custom type.
+type TypeFunc func(string) (any, error)
+
+// BodyDecoderFunc decodes a request body read from r into v.
+type BodyDecoderFunc func(r io.Reader, v any) error
+
+// ContentEncodingFunc returns a reader that decodes r, e.g. a gzip reader.
+type ContentEncodingFunc func(r io.Reader) (io.ReadCloser, error)
+
+type bodyDecoder struct {
+	contentType string
+	fn          BodyDecoderFunc
+}
+
+// InterfaceFunc returns a new concrete value for an interface type, usually a
+// pointer to a struct.
+type InterfaceFunc func() any
+
+type valuesDecoder struct {
+	tagName    string
+	ns         namespace
+	timeFormat string
+	// maxArraySize is the limit of indexed keys, see WithMaxArraySize
+	maxArraySize int
+	decoder      *form.Decoder
+	// fallbackTag is the tag used for fields without tagName, see
+	// WithQueryTagFallback. Their values are decoded by fallbackDecoder.
+	fallbackTag     string
+	fallbackDecoder *form.Decoder
+}
+
+func newValuesDecoder(tagName string) *valuesDecoder {
+	d := &valuesDecoder{
+		tagName:         tagName,
+		ns:              defaultNamespace,
+		decoder:         newFormDecoder(tagName),
+		fallbackDecoder: newFormDecoder(""),
+	}
+	d.setMaxArraySize(DefaultMaxArraySize)
+	return d
+}
+
+func newFormDecoder(tagName string) *form.Decoder {
+	dec := form.NewDecoder()
+	dec.RegisterTagNameFunc(tagNameFunc(tagName))
+	dec.SetMode(form.ModeExplicit)
+	dec.RegisterCustomTypeFunc(func(vals []string) (any, error) {
+		return parseDuration(vals[0])
+	}, time.Duration(0))
+	return dec
+}
+
+// decoders returns the decoder and the fallback decoder.
+func (d *valuesDecoder) decoders() []*form.Decoder {
+	return []*form.Decoder{d.decoder, d.fallbackDecoder}
+}
+
+func (d *valuesDecoder) setTagName(tagName string) {
+	d.tagName = tagName
+	d.decoder.RegisterTagNameFunc(tagNameFunc(tagName))
+}
+
+func (d *valuesDecoder) setFallbackTag(tagName string) {
+	d.fallbackTag = tagName
+	d.fallbackDecoder.RegisterTagNameFunc(tagNameFunc(tagName))
+}
+
+func (d *valuesDecoder) setMaxArraySize(n int) {
+	d.maxArraySize = n
+	for _, dec := range d.decoders() {
+		dec.SetMaxArraySize(uint(n))
+	}
+}
+
+func (d *valuesDecoder) registerType(t reflect.Type, fn TypeFunc) {
+	customFn := func(vals []string) (any, error) {
+		return fn(vals[0])
+	}
+	for _, dec := range d.decoders() {
+		dec.RegisterCustomTypeFunc(customFn, reflect.Zero(t).Interface())
+	}
+}
+
+func (d *valuesDecoder) setNamespace(ns namespace) {
+	d.ns = ns
+	for _, dec := range d.decoders() {
+		dec.SetNamespacePrefix(ns.prefix)
+		dec.SetNamespaceSuffix(ns.suffix)
+	}
+}
+
+// fields returns the tagged fields of v.
+func (d *valuesDecoder) fields(v any) []valueField {
+	return d.typeFields(reflect.TypeOf(v))
+}
+
+// typeFields returns the tagged fields of type t.
+func (d *valuesDecoder) typeFields(t reflect.Type) []valueField {
+	return cachedValueFields(t, d.tagName, d.fallbackTag, d.ns)
+}
+
+// tagNameFunc returns a function that reads field names from tagName tags. The
+// form package only understands a single option, so all options except
+// omitempty are dropped.
+func tagNameFunc(tagName string) form.TagNameFunc {
+	return func(field reflect.StructField) string {
+		name, opts := parseTag(field.Tag.Get(tagName))
+		if hasOption(opts, "omitempty") {
+			return name + ",omitempty"
+		}
+		return name
+	}
+}
+
+func newEncoder(tagName string) *form.Encoder {
+	enc := form.NewEncoder()
+	enc.RegisterTagNameFunc(tagNameFunc(tagName))
+	enc.SetMode(form.ModeExplicit)
+	return enc
+}
+
+// New returns a Binder that uses the query, form, header and cookie tag names
+// in explicit mode, configured with opts.
+func New(opts ...Option) *Binder {
+	b := &Binder{
+		PathValueFunc:      defaultPathValueFunc,
+		MaxBodyBytes:       DefaultMaxBodyBytes,
+		MaxMultipartMemory: DefaultMaxMultipartMemory,
+		queryDecoder:       newValuesDecoder("query"),
+		formDecoder:        newValuesDecoder("form"),
+		headerDecoder:      newValuesDecoder("header"),
+		cookieDecoder:      newValuesDecoder("cookie"),
+		tagDecoders:        &sync.Map{},
+		queryEncoder:       newEncoder("query"),
+		formEncoder:        newEncoder("form"),
+		headerEncoder:      newEncoder("header"),
+		cookieEncoder:      newEncoder("cookie"),
+		mode:               form.ModeExplicit,
+		ns:                 defaultNamespace,
+		types:              make(map[reflect.Type]TypeFunc),
+		interfaces:         make(map[reflect.Type]InterfaceFunc),
+		contextKeys:        make(map[string]any),
+		encodings:          make(map[string]ContentEncodingFunc, len(defaultEncodings)),
+	}
+	for name, fn := range defaultEncodings {
+		b.encodings[name] = fn
+	}
+	for _, opt := range opts {
+		opt.apply(b)
+	}
+	return b
+}
+
+// Clone returns a copy of b with its own decoders and encoders. This makes it
+// possible to e.g. change a tag name for part of an application without
+// affecting b:
+//
+//	jsonBinder := b.Clone()
+//	jsonBinder.SetQueryTagName("json")
+func (b *Binder) Clone() *Binder {
+	c := New()
+	c.PathValueFunc = b.PathValueFunc
+	c.PathValueFuncOk = b.PathValueFuncOk
+	c.ValidateFunc = b.ValidateFunc
+	c.MaxBodyBytes = b.MaxBodyBytes
+	c.MaxMultipartMemory = b.MaxMultipartMemory
+	c.BodyTimeout = b.BodyTimeout
+	c.OnError = b.OnError
+	c.JSONDecode = b.JSONDecode
+	c.XMLDecode = b.XMLDecode
+	c.sources = b.sources
+	c.Flags = append([]Flag(nil), b.Flags...)
+	c.SetQueryTagName(b.queryDecoder.tagName)
+	c.SetFormTagName(b.formDecoder.tagName)
+	c.SetHeaderTagName(b.headerDecoder.tagName)
+	c.SetCookieTagName(b.cookieDecoder.tagName)
+	c.queryDecoder.setFallbackTag(b.queryDecoder.fallbackTag)
+	c.SetMode(b.mode)
+	c.SetNamespace(b.ns.prefix, b.ns.suffix)
+	for t, fn := range b.types {
+		c.RegisterType(t, fn)
+	}
+	for t, fn := range b.interfaces {
+		c.RegisterInterface(t, fn)
+	}
+	c.bodies = append(c.bodies, b.bodies...)
+	c.encodings = make(map[string]ContentEncodingFunc, len(b.encodings))
+	for name, fn := range b.encodings {
+		c.encodings[name] = fn
+	}
+	for name, key := range b.contextKeys {
+		c.RegisterContextKey(name, key)
+	}
+	c.separator = b.separator
+	c.setTimeFormat(b.timeFormat)
+	c.setMaxArraySize(b.queryDecoder.maxArraySize)
+	return c
+}
+
+// With returns a clone of b configured with opts. Like Clone, it should be
+// called once and the result reused instead of called for every request:
+//
+//	uploadBinder := bind.Default().With(bind.WithMaxBody(100 << 20))
+func (b *Binder) With(opts ...Option) *Binder {
+	c := b.Clone()
+	for _, opt := range opts {
+		opt.apply(c)
+	}
+	return c
+}
+
+func (b *Binder) setMaxArraySize(n int) {
+	for _, d := range b.valuesDecoders() {
+		d.setMaxArraySize(n)
+	}
+}
+
+func (b *Binder) setTimeFormat(layout string) {
+	b.timeFormat = layout
+	for _, d := range b.valuesDecoders() {
+		d.timeFormat = layout
+	}
+}
+
+// SetQueryTagName sets the tag name used to decode and encode query
+// parameters. It should not be called once the Binder is in use.
+func (b *Binder) SetQueryTagName(tagName string) {
+	b.queryDecoder.setTagName(tagName)
+	b.queryEncoder.RegisterTagNameFunc(tagNameFunc(tagName))
+}
+
+// SetFormTagName sets the tag name used to decode and encode form data. It
+// should not be called once the Binder is in use.
+func (b *Binder) SetFormTagName(tagName string) {
+	b.formDecoder.setTagName(tagName)
+	b.formEncoder.RegisterTagNameFunc(tagNameFunc(tagName))
+}
+
+// SetHeaderTagName sets the tag name used to decode and encode headers. It
+// should not be called once the Binder is in use.
+func (b *Binder) SetHeaderTagName(tagName string) {
+	b.headerDecoder.setTagName(tagName)
+	b.headerEncoder.RegisterTagNameFunc(tagNameFunc(tagName))
+}
+
+// SetCookieTagName sets the tag name used to decode cookies. It should not be
+// called once the Binder is in use.
+func (b *Binder) SetCookieTagName(tagName string) {
+	b.cookieDecoder.setTagName(tagName)
+	b.cookieEncoder.RegisterTagNameFunc(tagNameFunc(tagName))
+}
+
+// QueryDecoder returns the go-playground/form decoder of query parameters, e.g.
+// to register a custom type function for query parameters only. The decoder is
+// shared by all requests, so it should only be configured before the Binder is
+// in use. Settings made on it directly aren't copied by Clone. Use
+// SetQueryTagName, SetMode, SetNamespace, RegisterType and WithMaxArraySize
+// instead of the corresponding decoder methods, since the Binder keeps track
+// of those settings itself.
+func (b *Binder) QueryDecoder() *form.Decoder {
+	return b.queryDecoder.decoder
+}
+
+// FormDecoder returns the go-playground/form decoder of form data, see
+// QueryDecoder.
+func (b *Binder) FormDecoder() *form.Decoder {
+	return b.formDecoder.decoder
+}
+
+// HeaderDecoder returns the go-playground/form decoder of headers, see
+// QueryDecoder.
+func (b *Binder) HeaderDecoder() *form.Decoder {
+	return b.headerDecoder.decoder
+}
+
+// CookieDecoder returns the go-playground/form decoder of cookies, see
+// QueryDecoder.
+func (b *Binder) CookieDecoder() *form.Decoder {
+	return b.cookieDecoder.decoder
+}
+
+// SetMode sets the mode of all decoders and encoders. It should not be called
+// once the Binder is in use.
+func (b *Binder) SetMode(mode form.Mode) {
+	b.mode = mode
+	for _, d := range b.valuesDecoders() {
+		for _, dec := range d.decoders() {
+			dec.SetMode(mode)
+		}
+	}
+	for _, e := range b.encoders() {
+		e.SetMode(mode)
+	}
+}
+
+// SetNamespace sets the prefix and suffix that are put around the names of
+// nested struct fields in query, form, header and cookie keys. The default is
+// a "." prefix and no suffix, e.g. user.name=x. Use "[" and "]" for bracketed
+// keys as sent by many frontend libraries:
+//
+//	b.SetNamespace("[", "]")
+//
+// Nested structs are then bound from user[name]=x, slices of structs from
+// items[0][qty]=2 and maps from attrs[key]=x. A trailing [] in a key is always
+// ignored, so roles[]=a&roles[]=b binds to a roles slice. It should not be
+// called once the Binder is in use.
+func (b *Binder) SetNamespace(prefix, suffix string) {
+	b.ns = namespace{prefix: prefix, suffix: suffix}
+	for _, d := range b.valuesDecoders() {
+		d.setNamespace(b.ns)
+	}
+	for _, e := range b.encoders() {
+		e.SetNamespacePrefix(prefix)
+		e.SetNamespaceSuffix(suffix)
+	}
+}
+
+// RegisterType registers a conversion function for values of type t. The
+// function is used for every source (path, query, form, header and cookie) and
+// must return a value of type t. It should not be called once the Binder is in
+// use.
+func (b *Binder) RegisterType(t reflect.Type, fn TypeFunc) {
+	b.types[t] = fn
+	for _, d := range b.valuesDecoders() {
+		d.registerType(t, fn)
+	}
+}
+
+// RegisterInterface registers a factory function for interface type t. Nil
+// struct fields of type t are set to a new value returned by fn before the body,
+// query or form values are decoded, so that they are decoded into the concrete
+// type. It should not be called once the Binder is in use.
+//
+//	b.RegisterInterface(reflect.TypeOf((*Shape)(nil)).Elem(), func() any {
+//		return &Circle{}
+//	})
+func (b *Binder) RegisterInterface(t reflect.Type, fn InterfaceFunc) {
+	b.interfaces[t] = fn
+}
+
+// allocInterfaces sets nil interface fields of v that have a registered
+// factory function.
+func (b *Binder) allocInterfaces(v any) {
+	if len(b.interfaces) == 0 {
+		return
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return
+	}
+	b.allocInterfaceFields(rv.Elem(), map[visitedPtr]struct{}{{rv.Pointer(), rv.Type()}: {}})
+}
+
+// visitedPtr identifies a followed pointer. The type is part of it because a
+// pointer to a struct and to its first field share their address.
+type visitedPtr struct {
+	ptr uintptr
+	typ reflect.Type
+}
+
+// allocInterfaceFields allocates the nil interface fields of struct v. visited
+// holds the pointers already followed, so that cyclic values are walked once.
+func (b *Binder) allocInterfaceFields(v reflect.Value, visited map[visitedPtr]struct{}) {
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		f := v.Field(i)
+		if !f.CanSet() {
+			continue
+		}
+		switch f.Kind() {
+		case reflect.Interface:
+			if fn, ok := b.interfaces[sf.Type]; ok && f.IsNil() {
+				if c := reflect.ValueOf(fn()); c.IsValid() && c.Type().Implements(sf.Type) {
+					f.Set(c)
+				}
+			}
+		case reflect.Struct:
+			b.allocInterfaceFields(f, visited)
+		case reflect.Ptr:
+			if f.IsNil() {
+				continue
+			}
+			key := visitedPtr{f.Pointer(), f.Type()}
+			if _, ok := visited[key]; ok {
+				continue
+			}
+			visited[key] = struct{}{}
+			b.allocInterfaceFields(f.Elem(), visited)
+		}
+	}
+}
+
+// RegisterBodyDecoder registers a decoder for bodies with a content type that
+// starts with contentType, e.g. application/cbor. Registered decoders take
+// precedence over the built-in ones, later registrations over earlier ones.
+// It should not be called once the Binder is in use.
+func (b *Binder) RegisterBodyDecoder(contentType string, fn BodyDecoderFunc) {
+	b.bodies = append(b.bodies, bodyDecoder{contentType: contentType, fn: fn})
+}
+
+// RegisterContentEncoding registers a decoder for bodies with the given
+// Content-Encoding, e.g. br. gzip, x-gzip and deflate are supported by
+// default, a nil fn removes support for an encoding. Bodies with other
+// encodings result in an *UnsupportedContentEncodingError. It should not be
+// called once the Binder is in use.
+func (b *Binder) RegisterContentEncoding(name string, fn ContentEncodingFunc) {
+	name = strings.ToLower(name)
+	if fn == nil {
+		delete(b.encodings, name)
+		return
+	}
+	b.encodings[name] = fn
+}
+
+// RegisterContextKey maps the ctx tag name to a context key. Fields tagged with
+// `ctx:"name"` are then bound to the value of key in the request context:
+//
+//	type tenantKey struct{}
+//	b.RegisterContextKey("tenant_id", tenantKey{})
+//
+// It should not be called once the Binder is in use.
+func (b *Binder) RegisterContextKey(name string, key any) {
+	b.contextKeys[name] = key
+}
+
+func (b *Binder) bodyDecoder(contentType string) BodyDecoderFunc {
+	for i := len(b.bodies) - 1; i >= 0; i-- {
+		if strings.HasPrefix(contentType, b.bodies[i].contentType) {
+			return b.bodies[i].fn
+		}
+	}
+	return nil
+}
+
+func (b *Binder) valuesDecoders() []*valuesDecoder {
+	return []*valuesDecoder{b.queryDecoder, b.formDecoder, b.headerDecoder, b.cookieDecoder}
+}
+
+func (b *Binder) encoders() []*form.Encoder {
+	return []*form.Encoder{b.queryEncoder, b.formEncoder, b.headerEncoder, b.cookieEncoder}
+}
+
+func (b *Binder) flags(flags []Flag) []Flag {
+	if len(b.Flags) == 0 {
+		return flags
+	}
+	return append(b.Flags[:len(b.Flags):len(b.Flags)], flags...)
+}
+
+// EncodeQuery encodes the query tagged fields of v. Fields with the omitempty
+// tag option are left out if they have their zero value, e.g.
+// `query:"page,omitempty"`. This also applies to EncodeForm, EncodeHeader and
+// EncodeCookie.
+func (b *Binder) EncodeQuery(v any) (url.Values, error) {
+	return b.queryEncoder.Encode(v)
+}
+
+func (b *Binder) EncodeForm(v any) (url.Values, error) {
+	return b.formEncoder.Encode(v)
+}
+
+func (b *Binder) EncodeHeader(v any) (http.Header, error) {
+	vals, err := b.headerEncoder.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+	// tags don't have to be canonical, e.g. header:"x-api-key"
+	header := make(http.Header, len(vals))
+	for k, vs := range vals {
+		header[http.CanonicalHeaderKey(k)] = append(header[http.CanonicalHeaderKey(k)], vs...)
+	}
+	return header, nil
+}
+
+// EncodeCookie encodes the cookie tagged fields of v as cookies, e.g. to set
+// them with http.SetCookie. Empty values are skipped and slices result in a
+// cookie per element. Attributes like Path and MaxAge are left unset.
+func (b *Binder) EncodeCookie(v any) ([]*http.Cookie, error) {
+	vals, err := b.cookieEncoder.Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(vals))
+	for k := range vals {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var cookies []*http.Cookie
+	for _, k := range keys {
+		for _, val := range vals[k] {
+			if val != "" {
+				cookies = append(cookies, &http.Cookie{Name: k, Value: val})
+			}
+		}
+	}
+	return cookies, nil
+}
+
+// EncodePath returns the path tagged field values of v keyed by parameter
+// name. Nil pointers are omitted. Slices are joined and times formatted like
+// Path expects them, so the separator and time format of b apply.
+func (b *Binder) EncodePath(v any) (map[string]string, error) {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil, &form.InvalidEncodeError{Type: reflect.TypeOf(v)}
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return nil, &form.InvalidEncodeError{Type: reflect.TypeOf(v)}
+	}
+
+	params := make(map[string]string)
+	for _, f := range cachedTagFields(val.Type(), "path") {
+		fieldVal, err := val.FieldByIndexErr(f.index)
+		if err != nil {
+			continue
+		}
+		if fieldVal.Kind() == reflect.Ptr && fieldVal.IsNil() {
+			continue
+		}
+		strVal, err := b.formatField(fieldVal, f.tag)
+		if err != nil {
+			return nil, err
+		}
+		params[f.name] = strVal
+	}
+
+	return params, nil
+}
+
+// EncodeURL builds a URL by replacing the {param} placeholders in urlTemplate
+// with the escaped path tagged values of v and adding the query tagged values
+// to the query string, e.g. /users/{id} becomes /users/1?active=true. A
+// placeholder without a value, e.g. of a nil pointer field, is an error.
+func (b *Binder) EncodeURL(urlTemplate string, v any) (string, error) {
+	params, err := b.EncodePath(v)
+	if err != nil {
+		return "", err
+	}
+	u, err := expandPath(urlTemplate, params)
+	if err != nil {
+		return "", err
+	}
+
+	vals, err := b.EncodeQuery(v)
+	if err != nil {
+		return "", err
+	}
+	if len(vals) > 0 {
+		if strings.Contains(u, "?") {
+			u += "&" + vals.Encode()
+		} else {
+			u += "?" + vals.Encode()
+		}
+	}
+	return u, nil
+}
+
+// EncodeRequest is the client side counterpart of Request. It builds a request
+// by replacing the {param} placeholders in urlTemplate with the path tagged
+// values of v and setting the header tagged values as headers. For GET, HEAD
+// and DELETE requests the query tagged values are added to the query string,
+// for other methods the form tagged values are sent as a form body or, if
+// there are none, v is sent as a JSON body.
+func (b *Binder) EncodeRequest(method, urlTemplate string, v any) (*http.Request, error) {
+	var u string
+	var body io.Reader
+	var contentType string
+
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodDelete {
+		var err error
+		if u, err = b.EncodeURL(urlTemplate, v); err != nil {
+			return nil, err
+		}
+	} else {
+		params, err := b.EncodePath(v)
+		if err != nil {
+			return nil, err
+		}
+		if u, err = expandPath(urlTemplate, params); err != nil {
+			return nil, err
+		}
+
+		vals, err := b.EncodeForm(v)
+		if err != nil {
+			return nil, err
+		}
+		if len(vals) > 0 {
+			body = strings.NewReader(vals.Encode())
+			contentType = "application/x-www-form-urlencoded"
+		} else {
+			j, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(j)
+			contentType = "application/json"
+		}
+	}
+
+	r, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+
+	header, err := b.EncodeHeader(v)
+	if err != nil {
+		return nil, err
+	}
+	for k, vals := range header {
+		for _, val := range vals {
+			r.Header.Add(k, val)
+		}
+	}
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	return r, nil
+}
+
+func (b *Binder) DecodeQuery(vals url.Values, v any, flags ...Flag) error {
+	b.allocInterfaces(v)
+	return decodeValues(b.queryDecoder, vals, v, b.flags(flags))
+}
+
+func (b *Binder) DecodeForm(vals url.Values, v any, flags ...Flag) error {
+	b.allocInterfaces(v)
+	return decodeValues(b.formDecoder, vals, v, b.flags(flags))
+}
+
+// DecodeValues binds vals to the fields of v tagged with tagName, with the
+// same tag options and flags as DecodeQuery. This makes it possible to bind
+// sources that have no dedicated method, e.g. the params of a custom router:
+//
+//	type Request struct {
+//		ID   string `param:"id"`
+//		Page int    `query:"page"`
+//	}
+//	err := b.DecodeValues(params, "param", &req)
+//
+// The query, form, header and cookie tag names use the decoders of the
+// corresponding source.
+func (b *Binder) DecodeValues(vals url.Values, tagName string, v any, flags ...Flag) error {
+	b.allocInterfaces(v)
+	return decodeValues(b.tagDecoder(tagName), vals, v, b.flags(flags))
+}
+
+// tagDecoder returns the decoder for tagName, other tag names than those of
+// the built-in sources get a decoder with the settings of b on first use.
+func (b *Binder) tagDecoder(tagName string) *valuesDecoder {
+	for _, d := range b.valuesDecoders() {
+		if d.tagName == tagName {
+			return d
+		}
+	}
+	if d, ok := b.tagDecoders.Load(tagName); ok {
+		return d.(*valuesDecoder)
+	}
+
+	d := newValuesDecoder(tagName)
+	for _, dec := range d.decoders() {
+		dec.SetMode(b.mode)
+	}
+	d.setNamespace(b.ns)
+	d.setMaxArraySize(b.queryDecoder.maxArraySize)
+	d.timeFormat = b.timeFormat
+	for t, fn := range b.types {
+		d.registerType(t, fn)
+	}
+	actual, _ := b.tagDecoders.LoadOrStore(tagName, d)
+	return actual.(*valuesDecoder)
+}
+
+// DecodeHeader binds header to v. Tag names match regardless of canonical
+// case. Repeated headers are bound to slice fields with one element per
+// header line, other fields receive the first value.
+func (b *Binder) DecodeHeader(header http.Header, v any, flags ...Flag) error {
+	flags = b.flags(flags)
+	vals := withHeaderKeys(url.Values(header), b.headerDecoder.fields(v))
+	if hasFlag(flags, SplitHeaders) {
+		vals = withSplitValues(vals, b.headerDecoder.fields(v))
+	}
+	return decodeValues(b.headerDecoder, vals, v, flags)
+}
+
+func (b *Binder) DecodeCookie(cookies []*http.Cookie, v any, flags ...Flag) error {
+	vals := make(url.Values, len(cookies))
+	for _, c := range cookies {
+		vals.Add(c.Name, c.Value)
+	}
+	return decodeValues(b.cookieDecoder, vals, v, b.flags(flags))
+}
+
+func (b *Binder) PathValue(r *http.Request, k string) string {
+	v, _ := b.pathValue(r, k)
+	return v
+}
+
+func (b *Binder) hasPathValueFunc() bool {
+	return b.PathValueFuncOk != nil || b.PathValueFunc != nil
+}
+
+// pathValue looks up a path param with PathValueFuncOk if set, otherwise with
+// PathValueFunc. The latter can't tell absent params apart, so they're always
+// reported as found.
+func (b *Binder) pathValue(r *http.Request, k string) (string, bool) {
+	if b.PathValueFuncOk != nil {
+		return b.PathValueFuncOk(r, k)
+	}
+	if b.PathValueFunc != nil {
+		return b.PathValueFunc(r, k), true
+	}
+	return "", false
+}
+
+// Request binds context values, path variables, headers, cookies and,
+// depending on the method, query parameters or the body to v. Context binding
+// is skipped if no context keys are registered. Path binding is skipped if
+// neither PathValueFunc nor PathValueFuncOk is set, call Path directly to get
+// ErrPathValueFuncNotSet instead. Sources that aren't enabled with WithSources
+// are skipped. Binding errors are wrapped in a *SourceError.
+//
+// v must be a non-nil pointer, usually to a struct. A map is only bound from
+// the query parameters or the body. Any other value, like a slice, an
+// interface or a scalar, is only bound from the body, e.g. a JSON array. A
+// value that isn't a pointer, a nil pointer or a pointer to a pointer results
+// in an *InvalidTargetError.
+func (b *Binder) Request(r *http.Request, v any, flags ...Flag) error {
+	if err := checkTarget(v); err != nil {
+		return err
+	}
+
+	flags = b.flags(flags)
+
+	// skip the decode passes for sources v has no tagged fields for
+	enabled := b.enabledSources()
+	sources := b.Sources(v) & enabled
+
+	if len(b.contextKeys) > 0 && sources.Has(SourceContext) {
+		if err := b.Context(r, v, flags...); err != nil {
+			return b.sourceError(r, "context", err)
+		}
+	}
+
+	if b.hasPathValueFunc() && sources.Has(SourcePath) {
+		if err := b.Path(r, v, flags...); err != nil {
+			return b.sourceError(r, "path", err)
+		}
+	}
+
+	if sources.Has(SourceHeader) {
+		if err := b.Header(r, v, flags...); err != nil {
+			return b.sourceError(r, "header", err)
+		}
+	}
+
+	if sources.Has(SourceCookie) {
+		if err := b.Cookie(r, v, flags...); err != nil {
+			return b.sourceError(r, "cookie", err)
+		}
+	}
+
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodDelete {
+		if sources.Has(SourceQuery) {
+			if err := b.Query(r, v, flags...); err != nil {
+				return b.sourceError(r, "query", err)
+			}
+		}
+	} else if enabled.Has(SourceBody) || enabled.Has(SourceForm) && isFormContentType(r.Header.Get("Content-Type")) {
+		if err := b.Body(r, v, flags...); err != nil {
+			return b.sourceError(r, "body", err)
+		}
+	}
+
+	if b.ValidateFunc != nil {
+		if err := b.ValidateFunc(v); err != nil {
+			return b.validationError(r, &ValidationError{Err: err})
+		}
+	}
+
+	if validator, ok := v.(Validator); ok {
+		if err := validator.ValidateBind(); err != nil {
+			return b.validationError(r, err)
+		}
+	}
+
+	if validatable, ok := v.(Validatable); ok {
+		if err := validatable.Validate(); err != nil {
+			return b.validationError(r, err)
+		}
+	}
+
+	return nil
+}
+
+// isFormContentType reports whether ct is the content type of a URL encoded or
+// multipart form body.
+func isFormContentType(ct string) bool {
+	return strings.HasPrefix(ct, "application/x-www-form-urlencoded") || strings.HasPrefix(ct, "multipart/form-data")
+}
+
+// sourceError wraps a binding error of source in a *SourceError and reports
+// it to OnError.
+func (b *Binder) sourceError(r *http.Request, source string, err error) error {
+	if b.OnError != nil {
+		b.OnError(r, source, err)
+	}
+	return &SourceError{Source: source, Err: err}
+}
+
+// validationError reports a validation error to OnError.
+func (b *Binder) validationError(r *http.Request, err error) error {
+	if b.OnError != nil {
+		b.OnError(r, "validation", err)
+	}
+	return err
+}
+
+// checkTarget returns an *InvalidTargetError if v is not a non-nil pointer to a
+// non-pointer value.
+func checkTarget(v any) error {
+	val := reflect.ValueOf(v)
+	switch {
+	case val.Kind() != reflect.Ptr:
+		return &InvalidTargetError{Type: reflect.TypeOf(v), Reason: "not a pointer"}
+	case val.IsNil():
+		return &InvalidTargetError{Type: val.Type(), Reason: "nil pointer"}
+	case val.Elem().Kind() == reflect.Ptr:
+		return &InvalidTargetError{Type: val.Type(), Reason: "pointer to a pointer"}
+	}
+	return nil
+}
+
+// SafeRequest is like Request but recovers from panics while binding, e.g.
+// caused by an exotic field type or a faulty TypeFunc, and returns them as a
+// *PanicError instead of crashing the handler.
+func (b *Binder) SafeRequest(r *http.Request, v any, flags ...Flag) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = &PanicError{Value: rec, Stack: debug.Stack()}
+		}
+	}()
+	return b.Request(r, v, flags...)
+}
+
+// Query binds the query parameters of r to v. A string field tagged with
+// `query:",raw"` receives the raw, undecoded query string.
+func (b *Binder) Query(r *http.Request, v any, flags ...Flag) error {
+	if err := b.DecodeQuery(queryValues(r, b.flags(flags)), v, flags...); err != nil {
+		return err
+	}
+	setRaw(v, b.queryDecoder.fields(v), r.URL.RawQuery)
+	return nil
+}
+
+// QueryLeftover binds the query parameters of r like Query and returns the
+// parameters that don't match a field of struct v, e.g. to forward them.
+func (b *Binder) QueryLeftover(r *http.Request, v any, flags ...Flag) (url.Values, error) {
+	if err := b.Query(r, v, flags...); err != nil {
+		return nil, err
+	}
+	fields := b.queryDecoder.fields(v)
+	flags = b.flags(flags)
+	return unboundValues(queryValues(r, flags), fields, hasFlag(flags, CaseInsensitive)), nil
+}
+
+// Body binds the request body to v according to its content type. Form
+// bodies are bound from the body values only, query parameters are ignored.
+func (b *Binder) Body(r *http.Request, v any, flags ...Flag) error {
+	flags = b.flags(flags)
+
+	ok, closeBody, err := b.openBody(r, flags)
+	if !ok {
+		return err
+	}
+	defer closeBody()
+
+	if hasFlag(flags, RewindBody) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		defer rewindBody(r, body)
+	}
+
+	ct := r.Header.Get("Content-Type")
+
+	// form data is parsed by the request so that r.Form and r.MultipartForm
+	// are available afterwards
+	switch {
+	case b.bodyDecoder(ct) != nil:
+	case strings.HasPrefix(ct, "application/x-www-form-urlencoded") && !hasFlag(flags, LiteralPlus):
+		if err := r.ParseForm(); err != nil {
+			return err
+		}
+		// only the body values, r.Form also holds the query parameters
+		return b.DecodeForm(r.PostForm, v, flags...)
+	case strings.HasPrefix(ct, "multipart/form-data"):
+		if err := r.ParseMultipartForm(b.MaxMultipartMemory); err != nil {
+			return err
+		}
+		// the values and files of the multipart body are bound in one pass,
+		// query parameters that ParseMultipartForm adds to r.Form are not
+		if err := b.DecodeForm(url.Values(r.MultipartForm.Value), v, flags...); err != nil {
+			return err
+		}
+		return b.decodeFiles(r.MultipartForm.File, v)
+	}
+
+	return b.DecodeBody(r.Body, ct, v, flags...)
+}
+
+// rewindBody replaces the body of r with body, see the RewindBody flag. body
+// is decompressed, so the headers are updated to match it.
+func rewindBody(r *http.Request, body []byte) {
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.Header.Del("Content-Encoding")
+	r.ContentLength = int64(len(body))
+	if r.Header.Get("Content-Length") != "" {
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+}
+
+// openBody reports whether r has a non empty body and prepares it for
+// reading: the read timeout is applied first, so that it also covers the
+// check for an empty body, then the body is decompressed if needed and the
+// size limit is applied. The returned function releases the decompressor and
+// the timeout.
+func (b *Binder) openBody(r *http.Request, flags []Flag) (bool, func(), error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return false, nil, nil
+	}
+
+	closeBody := func() {}
+
+	if b.BodyTimeout > 0 {
+		ctx, cancel := context.WithTimeout(r.Context(), b.BodyTimeout)
+		r.Body = &timeoutReader{rc: r.Body, ctx: ctx}
+		closeBody = cancel
+	}
+
+	if ok, err := hasBody(r); !ok {
+		closeBody()
+		return false, nil, err
+	}
+
+	if ce := r.Header.Get("Content-Encoding"); ce != "" {
+		body, closeDecompressors, err := b.decompress(r.Body, ce)
+		if err != nil {
+			closeBody()
+			return false, nil, err
+		}
+		cancel := closeBody
+		closeBody = func() {
+			closeDecompressors()
+			cancel()
+		}
+		r.Body = readCloser{body, r.Body}
+	}
+
+	// the limit applies to the decompressed body
+	if b.MaxBodyBytes > 0 && !hasFlag(flags, NoBodyLimit) {
+		r.Body = &limitReader{http.MaxBytesReader(nil, r.Body, b.MaxBodyBytes)}
+	}
+
+	return true, closeBody, nil
+}
+
+// limitReader wraps the *http.MaxBytesError of a http.MaxBytesReader in a
+// *BodyTooLargeError.
+type limitReader struct {
+	io.ReadCloser
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	n, err := l.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		err = &BodyTooLargeError{Limit: maxBytesErr.Limit, Err: err}
+	}
+	return n, err
+}
+
+// timeoutReader aborts reads when ctx is done. Reads are done in a separate
+// goroutine since a blocked Read can't be interrupted; after an abort the
+// reader fails and the pending read is abandoned.
+type timeoutReader struct {
+	rc  io.ReadCloser
+	ctx context.Context
+	buf []byte
+	err error
+}
+
+type readResult struct {
+	n   int
+	err error
+}
+
+func (t *timeoutReader) Read(p []byte) (int, error) {
+	if t.err != nil {
+		return 0, t.err
+	}
+	if len(t.buf) < len(p) {
+		t.buf = make([]byte, len(p))
+	}
+	buf := t.buf[:len(p)]
+	ch := make(chan readResult, 1)
+	go func() {
+		n, err := t.rc.Read(buf)
+		ch <- readResult{n, err}
+	}()
+	select {
+	case res := <-ch:
+		copy(p, buf[:res.n])
+		return res.n, res.err
+	case <-t.ctx.Done():
+		t.err = t.ctx.Err()
+		if errors.Is(t.err, context.DeadlineExceeded) {
+			t.err = ErrBodyTimeout
+		}
+		return 0, t.err
+	}
+}
+
+func (t *timeoutReader) Close() error {
+	return t.rc.Close()
+}
+
+// BodyStream decodes a JSON array body element by element. fn is called for
+// each element with a function that decodes the element into its argument,
+// elements that aren't decoded by fn are skipped. Returning an error from fn
+// stops the stream. The body must have content type application/json and start
+// with '['. Use the NoBodyLimit flag for bodies larger than MaxBodyBytes.
+//
+//	err := bind.BodyStream(r, func(decode func(any) error) error {
+//		rec := Record{}
+//		if err := decode(&rec); err != nil {
+//			return err
+//		}
+//		return store(rec)
+//	})
+func (b *Binder) BodyStream(r *http.Request, fn func(decode func(any) error) error, flags ...Flag) error {
+	flags = b.flags(flags)
+
+	ok, closeBody, err := b.openBody(r, flags)
+	if !ok {
+		return err
+	}
+	defer closeBody()
+
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		return fmt.Errorf("bind: can't stream content type %q", ct)
+	}
+
+	dec := json.NewDecoder(r.Body)
+	if hasFlag(flags, Strict) {
+		dec.DisallowUnknownFields()
+	}
+	if hasFlag(flags, UseNumber) {
+		dec.UseNumber()
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return errors.New("bind: body is not a JSON array")
+	}
+
+	for dec.More() {
+		decoded := false
+		decode := func(v any) error {
+			if decoded {
+				return errors.New("bind: element already decoded")
+			}
+			decoded = true
+			return dec.Decode(v)
+		}
+		if err := fn(decode); err != nil {
+			return err
+		}
+		if !decoded {
+			if err := dec.Decode(&json.RawMessage{}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// consume the closing bracket
+	_, err = dec.Token()
+	return err
+}
+
+// DecodeBody decodes a JSON, XML, YAML, TOML or URL encoded form body read
+// from r into v depending on contentType, or uses a decoder registered with
+// RegisterBodyDecoder. Other content types result in an
+// *UnsupportedMediaTypeError, unless the content type is empty or the
+// IgnoreUnsupportedMediaType flag is set. Multipart bodies are only supported
+// by Body.
+func (b *Binder) DecodeBody(r io.Reader, contentType string, v any, flags ...Flag) error {
+	flags = b.flags(flags)
+	ct := contentType
+
+	b.allocInterfaces(v)
+
+	if fn := b.bodyDecoder(ct); fn != nil {
+		return fn(r, v)
+	}
+
+	switch {
+	case strings.HasPrefix(ct, "application/json"):
+		if b.JSONDecode != nil {
+			return b.JSONDecode(r, v, flags)
+		}
+		dec := json.NewDecoder(r)
+		if hasFlag(flags, Strict) {
+			dec.DisallowUnknownFields()
+		}
+		if hasFlag(flags, UseNumber) {
+			dec.UseNumber()
+		}
+		return dec.Decode(v)
+	case strings.HasPrefix(ct, "application/xml") || strings.HasPrefix(ct, "text/xml"):
+		if b.XMLDecode != nil {
+			return b.XMLDecode(r, v, flags)
+		}
+		return xml.NewDecoder(r).Decode(v)
+	case strings.HasPrefix(ct, "application/yaml") || strings.HasPrefix(ct, "application/x-yaml") || strings.HasPrefix(ct, "text/yaml"):
+		dec := yaml.NewDecoder(r)
+		if hasFlag(flags, Strict) {
+			dec.KnownFields(true)
+		}
+		return dec.Decode(v)
+	case strings.HasPrefix(ct, "application/toml") || strings.HasPrefix(ct, "text/toml"):
+		md, err := toml.NewDecoder(r).Decode(v)
+		if err != nil {
+			return err
+		}
+		if hasFlag(flags, Strict) {
+			if undecoded := md.Undecoded(); len(undecoded) > 0 {
+				return fmt.Errorf("bind: unknown toml key %q", undecoded[0].String())
+			}
+		}
+		return nil
+	case strings.HasPrefix(ct, "application/x-www-form-urlencoded"):
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		vals, err := parseQuery(string(body), hasFlag(flags, LiteralPlus))
+		if err != nil {
+			return err
+		}
+		return b.DecodeForm(vals, v, flags...)
+	}
+	if ct == "" || hasFlag(flags, IgnoreUnsupportedMediaType) {
+		return nil
+	}
+	return &UnsupportedMediaTypeError{ContentType: ct}
+}
+
+func (b *Binder) Header(r *http.Request, v any, flags ...Flag) error {
+	return b.DecodeHeader(r.Header, v, flags...)
+}
+
+func (b *Binder) Cookie(r *http.Request, v any, flags ...Flag) error {
+	return b.DecodeCookie(r.Cookies(), v, flags...)
+}
+
+// Context binds the ctx tagged fields of v to values of the request context.
+// A context value is assigned as is if its type allows it, string values are
+// converted like path variables.
+func (b *Binder) Context(r *http.Request, v any, flags ...Flag) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return &form.InvalidDecoderError{Type: reflect.TypeOf(v)}
+	}
+
+	return b.setContext(r, val)
+}
+
+func (b *Binder) Path(r *http.Request, v any, flags ...Flag) error {
+	if !b.hasPathValueFunc() {
+		return ErrPathValueFuncNotSet
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return &form.InvalidDecoderError{Type: reflect.TypeOf(v)}
+	}
+
+	return b.setPath(r, val, b.flags(flags))
+}
@@ -0,0 +1,570 @@
+package bind
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/form/v4"
+)
+
+func TestBinder(t *testing.T) {
+	type t1 struct {
+		ID   int    `path:"id"`
+		Name string `query:"q" json:"name"`
+	}
+
+	t.Parallel()
+
+	b1 := New()
+	b1.PathValueFunc = func(r *http.Request, k string) string {
+		if k == "id" {
+			return "1"
+		}
+		return ""
+	}
+
+	b2 := New()
+	b2.SetQueryTagName("json")
+	b2.PathValueFunc = func(r *http.Request, k string) string {
+		if k == "id" {
+			return "2"
+		}
+		return ""
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?name=x&q=y", nil)
+
+	v1 := t1{}
+	if err := b1.Request(r, &v1); err != nil {
+		t.Error(err)
+	} else if v1.ID != 1 || v1.Name != "y" {
+		t.Errorf("got %+v, want {ID:1 Name:y}", v1)
+	}
+
+	v2 := t1{}
+	if err := b2.Request(r, &v2); err != nil {
+		t.Error(err)
+	} else if v2.ID != 2 || v2.Name != "x" {
+		t.Errorf("got %+v, want {ID:2 Name:x}", v2)
+	}
+
+	// package level PathValueFunc isn't used by a Binder, a new Binder only
+	// has the default that looks up http.ServeMux wildcards
+	b3 := New()
+	v3 := t1{}
+	err := b3.Path(r, &v3)
+	if b3.PathValueFunc == nil {
+		if !errors.Is(err, ErrPathValueFuncNotSet) {
+			t.Errorf("got %v, want %v", err, ErrPathValueFuncNotSet)
+		}
+	} else if err != nil || v3 != (t1{}) {
+		t.Errorf("got %v and %+v, want nil and %+v", err, v3, t1{})
+	}
+}
+
+func TestRequestImplicitMode(t *testing.T) {
+	type t1 struct {
+		Name string
+	}
+
+	b := New()
+	b.SetMode(form.ModeImplicit)
+
+	r, _ := http.NewRequest(http.MethodGet, "/?Name=x", nil)
+	v := t1{}
+	if err := b.Request(r, &v); err != nil {
+		t.Fatal(err)
+	} else if v.Name != "x" {
+		t.Errorf("got %q, want %q", v.Name, "x")
+	}
+
+	// the untagged field is ignored in explicit mode
+	v = t1{}
+	if err := New().Request(r, &v); err != nil {
+		t.Fatal(err)
+	} else if v.Name != "" {
+		t.Errorf("got %q, want empty string", v.Name)
+	}
+}
+
+func TestEncodeRequest(t *testing.T) {
+	type t1 struct {
+		ID     int    `path:"id"`
+		Token  string `header:"X-Token"`
+		Active bool   `query:"active"`
+		Name   string `form:"name"`
+	}
+
+	v := t1{ID: 1, Token: "secret", Active: true, Name: "a b"}
+
+	r, err := EncodeRequest(http.MethodGet, "/users/{id}", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.URL.String(); got != "/users/1?active=true" {
+		t.Errorf("got %q, want %q", got, "/users/1?active=true")
+	}
+	if got := r.Header.Get("X-Token"); got != "secret" {
+		t.Errorf("got %q, want %q", got, "secret")
+	}
+
+	r, err = EncodeRequest(http.MethodPost, "/users/{id}", &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := r.Header.Get("Content-Type"); got != "application/x-www-form-urlencoded" {
+		t.Errorf("got %q, want %q", got, "application/x-www-form-urlencoded")
+	}
+
+	// round trip
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		if k == "id" {
+			return "1"
+		}
+		return ""
+	})()
+	v2 := t1{}
+	if err := Request(r, &v2); err != nil {
+		t.Error(err)
+	} else if v2.ID != 1 || v2.Token != "secret" || v2.Name != "a b" {
+		t.Errorf("got %+v, want %+v", v2, t1{ID: 1, Token: "secret", Name: "a b"})
+	}
+}
+
+type testMoney struct {
+	Cents int64
+}
+
+func TestRegisterType(t *testing.T) {
+	type t1 struct {
+		Price  testMoney  `path:"price"`
+		Amount *testMoney `query:"amount"`
+	}
+
+	parseMoney := func(s string) (any, error) {
+		units, cents, _ := strings.Cut(s, ".")
+		u, err := strconv.ParseInt(units, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		c, err := strconv.ParseInt(cents, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return testMoney{Cents: u*100 + c}, nil
+	}
+
+	b := New()
+	b.RegisterType(reflect.TypeOf(testMoney{}), parseMoney)
+	b.PathValueFunc = func(r *http.Request, k string) string {
+		if k == "price" {
+			return "1.50"
+		}
+		return ""
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?amount=2.25", nil)
+
+	v := t1{}
+	if err := b.Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Price.Cents != 150 {
+		t.Errorf("got %d, want %d", v.Price.Cents, 150)
+	}
+	if v.Amount == nil || v.Amount.Cents != 225 {
+		t.Errorf("got %v, want %d", v.Amount, 225)
+	}
+}
+
+func TestValidateFunc(t *testing.T) {
+	type t1 struct {
+		Limit int `query:"limit"`
+	}
+
+	errTooLarge := errors.New("limit too large")
+
+	b := New()
+	b.ValidateFunc = func(v any) error {
+		if v.(*t1).Limit > 10 {
+			return errTooLarge
+		}
+		return nil
+	}
+
+	var validationErr *ValidationError
+
+	r, _ := http.NewRequest(http.MethodGet, "/?limit=20", nil)
+	if err := b.Request(r, &t1{}); !errors.As(err, &validationErr) {
+		t.Errorf("got %v, want *ValidationError", err)
+	} else if !errors.Is(err, errTooLarge) {
+		t.Errorf("got %v, want %v", err, errTooLarge)
+	}
+
+	// binding errors are not validation errors
+	r, _ = http.NewRequest(http.MethodGet, "/?limit=x", nil)
+	if err := b.Request(r, &t1{}); err == nil {
+		t.Error("got nil, want error")
+	} else if errors.As(err, &validationErr) {
+		t.Errorf("got *ValidationError, want binding error")
+	}
+}
+
+func TestRegisterBodyDecoder(t *testing.T) {
+	type t1 struct {
+		Name string
+	}
+
+	b := New()
+	b.RegisterBodyDecoder("application/x-upper", func(r io.Reader, v any) error {
+		body, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		v.(*t1).Name = strings.ToUpper(string(body))
+		return nil
+	})
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("abc"))
+	r.Header.Set("Content-Type", "application/x-upper; charset=utf-8")
+
+	v := t1{}
+	if err := b.Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "ABC" {
+		t.Errorf("got %q, want %q", v.Name, "ABC")
+	}
+
+	// clones keep registered decoders
+	r, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader("def"))
+	r.Header.Set("Content-Type", "application/x-upper")
+	v = t1{}
+	if err := b.Clone().Body(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "DEF" {
+		t.Errorf("got %q, want %q", v.Name, "DEF")
+	}
+}
+
+type testTenantKey struct{}
+
+type testSubjectKey struct{}
+
+func TestContext(t *testing.T) {
+	type t1 struct {
+		TenantID int     `ctx:"tenant_id"`
+		Subject  *string `ctx:"subject" required:"true"`
+		Page     int     `query:"page"`
+	}
+
+	b := New()
+	b.RegisterContextKey("tenant_id", testTenantKey{})
+	b.RegisterContextKey("subject", testSubjectKey{})
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=2", nil)
+	ctx := context.WithValue(r.Context(), testTenantKey{}, "42")
+	ctx = context.WithValue(ctx, testSubjectKey{}, "alice")
+
+	v := t1{}
+	if err := b.Request(r.WithContext(ctx), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.TenantID != 42 || v.Subject == nil || *v.Subject != "alice" || v.Page != 2 {
+		t.Errorf("got %+v, want tenant 42, subject alice and page 2", v)
+	}
+
+	// values of the field type are assigned as is
+	ctx = context.WithValue(ctx, testTenantKey{}, 7)
+	v = t1{}
+	if err := b.Context(r.WithContext(ctx), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.TenantID != 7 {
+		t.Errorf("got %d, want %d", v.TenantID, 7)
+	}
+
+	var sourceErr *SourceError
+	if err := b.Request(r, &t1{}); !errors.Is(err, ErrMissingField) {
+		t.Errorf("got %v, want ErrMissingField", err)
+	} else if !errors.As(err, &sourceErr) || sourceErr.Source != "context" {
+		t.Errorf("got %v, want context *SourceError", err)
+	}
+
+	ctx = context.WithValue(ctx, testTenantKey{}, 1.5)
+	if err := b.Context(r.WithContext(ctx), &t1{}); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestSourceError(t *testing.T) {
+	type t1 struct {
+		ID    int    `path:"id"`
+		Page  int    `query:"page"`
+		Token string `header:"X-Token" required:"true"`
+	}
+
+	b := New()
+	b.PathValueFunc = func(r *http.Request, k string) string {
+		return "1"
+	}
+
+	tests := []struct {
+		method string
+		url    string
+		header string
+		source string
+	}{
+		{http.MethodGet, "/?page=x", "t", "query"},
+		{http.MethodGet, "/?page=1", "", "header"},
+		{http.MethodPost, "/", "t", "body"},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.method, test.url, strings.NewReader("{"))
+		r.Header.Set("Content-Type", "application/json")
+		if test.header != "" {
+			r.Header.Set("X-Token", test.header)
+		}
+		var sourceErr *SourceError
+		if err := b.Request(r, &t1{}); !errors.As(err, &sourceErr) {
+			t.Errorf("got %v, want *SourceError", err)
+		} else if sourceErr.Source != test.source {
+			t.Errorf("got %q, want %q", sourceErr.Source, test.source)
+		} else if errors.Unwrap(err) == nil {
+			t.Error("got nil, want wrapped error")
+		} else if want := "bind: " + test.source + ": " + strings.TrimPrefix(sourceErr.Err.Error(), "bind: "); err.Error() != want {
+			t.Errorf("got %q, want %q", err, want)
+		} else if strings.Count(err.Error(), "bind: ") != 1 {
+			t.Errorf("got %q, want a single bind: prefix", err)
+		}
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=1", nil)
+	r.Header.Set("X-Token", "t")
+	if err := b.Request(r, &t1{}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSetNamespace(t *testing.T) {
+	type item struct {
+		ID  string `form:"id"`
+		Qty int    `form:"qty"`
+	}
+	type user struct {
+		Name  string   `form:"name"`
+		Roles []string `form:"roles"`
+	}
+	type t1 struct {
+		User  user   `form:"user"`
+		Items []item `form:"items"`
+	}
+
+	b := New()
+	b.SetNamespace("[", "]")
+
+	body := "user[name]=x&user[roles][]=a&user[roles][]=b&items[0][id]=i1&items[0][qty]=2&items[1][id]=i2"
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	v := t1{}
+	if err := b.Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	want := t1{
+		User:  user{Name: "x", Roles: []string{"a", "b"}},
+		Items: []item{{ID: "i1", Qty: 2}, {ID: "i2"}},
+	}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	vals, err := b.EncodeForm(t1{User: user{Name: "x"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := vals.Get("user[name]"); got != "x" {
+		t.Errorf("got %q, want %q", got, "x")
+	}
+}
+
+func TestClone(t *testing.T) {
+	type t1 struct {
+		Name string `query:"q" json:"name"`
+	}
+
+	b := Default().Clone()
+	b.SetQueryTagName("json")
+
+	r, _ := http.NewRequest(http.MethodGet, "/?name=x&q=y", nil)
+
+	v1 := t1{}
+	if err := b.Query(r, &v1); err != nil {
+		t.Error(err)
+	} else if v1.Name != "x" {
+		t.Errorf("got %q, want %q", v1.Name, "x")
+	}
+
+	// the default binder is not affected
+	v2 := t1{}
+	if err := Query(r, &v2); err != nil {
+		t.Error(err)
+	} else if v2.Name != "y" {
+		t.Errorf("got %q, want %q", v2.Name, "y")
+	}
+
+	// neither by changes to the result of Default
+	d := Default()
+	d.SetQueryTagName("json")
+	d.MaxBodyBytes = 1
+	v3 := t1{}
+	if err := d.Query(r, &v3); err != nil {
+		t.Error(err)
+	} else if v3.Name != "x" {
+		t.Errorf("got %q, want %q", v3.Name, "x")
+	}
+	v3 = t1{}
+	if err := Query(r, &v3); err != nil {
+		t.Error(err)
+	} else if v3.Name != "y" {
+		t.Errorf("got %q, want %q", v3.Name, "y")
+	}
+	if Default().MaxBodyBytes != MaxBodyBytes {
+		t.Errorf("got %d, want %d", Default().MaxBodyBytes, MaxBodyBytes)
+	}
+}
+
+type testShape interface {
+	Area() float64
+}
+
+type testSquare struct {
+	Side float64 `json:"side"`
+}
+
+func (s *testSquare) Area() float64 { return s.Side * s.Side }
+
+func TestRegisterInterface(t *testing.T) {
+	type t1 struct {
+		Name  string    `json:"name"`
+		Shape testShape `json:"shape"`
+	}
+
+	b := New()
+	b.RegisterInterface(reflect.TypeOf((*testShape)(nil)).Elem(), func() any {
+		return &testSquare{}
+	})
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"sq","shape":{"side":3}}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	v := t1{}
+	if err := b.Request(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Shape == nil || v.Shape.Area() != 9 {
+		t.Errorf("got %v, want area %d", v.Shape, 9)
+	}
+
+	// without a factory function json can't decode into the interface
+	r, _ = http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"shape":{"side":3}}`))
+	r.Header.Set("Content-Type", "application/json")
+	if err := New().Request(r, &t1{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestRegisterInterfaceCycle(t *testing.T) {
+	type node struct {
+		Shape testShape `json:"shape"`
+		Next  *node     `json:"-"`
+	}
+
+	b := New()
+	b.RegisterInterface(reflect.TypeOf((*testShape)(nil)).Elem(), func() any {
+		return &testSquare{}
+	})
+
+	// a cyclic value is walked once instead of forever
+	v := node{}
+	v.Next = &v
+	if err := b.DecodeBody(strings.NewReader(`{"shape":{"side":2}}`), "application/json", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Shape == nil || v.Shape.Area() != 4 {
+		t.Errorf("got %v, want area %d", v.Shape, 4)
+	}
+}
+
+func TestDecoderAccessors(t *testing.T) {
+	type t1 struct {
+		Amount testMoney `query:"amount" form:"amount"`
+	}
+
+	b := New()
+	b.QueryDecoder().RegisterCustomTypeFunc(func(vals []string) (any, error) {
+		return testMoney{Cents: int64(len(vals[0]))}, nil
+	}, testMoney{})
+
+	v := t1{}
+	if err := b.DecodeQuery(url.Values{"amount": {"abc"}}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Amount.Cents != 3 {
+		t.Errorf("got %d, want 3", v.Amount.Cents)
+	}
+
+	for _, dec := range []*form.Decoder{b.FormDecoder(), b.HeaderDecoder(), b.CookieDecoder()} {
+		if dec == nil || dec == b.QueryDecoder() {
+			t.Error("expected a separate decoder per source")
+		}
+	}
+}
+
+func TestDecodeValues(t *testing.T) {
+	type t1 struct {
+		ID    string    `param:"id"`
+		IDs   []int     `param:"ids" delim:","`
+		Price testMoney `param:"price"`
+		Page  int       `query:"page"`
+	}
+
+	b := New()
+	b.RegisterType(reflect.TypeOf(testMoney{}), func(s string) (any, error) {
+		n, err := strconv.ParseInt(s, 10, 64)
+		return testMoney{Cents: n}, err
+	})
+
+	params := url.Values{"id": {"a"}, "ids": {"1,2"}, "price": {"150"}, "page": {"3"}}
+
+	v := t1{}
+	if err := b.DecodeValues(params, "param", &v); err != nil {
+		t.Fatal(err)
+	}
+	want := t1{ID: "a", IDs: []int{1, 2}, Price: testMoney{Cents: 150}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	if err := b.DecodeValues(params, "query", &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Page != 3 {
+		t.Errorf("got page %d, want 3", v.Page)
+	}
+
+	err := b.DecodeValues(url.Values{"price": {"x"}}, "param", &t1{})
+	var errs form.DecodeErrors
+	if !errors.As(err, &errs) || errs["price"] == nil {
+		t.Errorf("got %v, want decode error for price", err)
+	}
+}
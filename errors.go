@@ -0,0 +1,392 @@
+package bind
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// StatusCoder is implemented by errors that suggest a HTTP status code.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// StatusCode returns the HTTP status code suggested by err or the first error
+// in its chain that implements StatusCoder. A body that exceeds the size limit
+// results in 413 Request Entity Too Large, ErrBodyTimeout in 408 Request
+// Timeout and any other error in 400 Bad Request.
+func StatusCode(err error) int {
+	var sc StatusCoder
+	if errors.As(err, &sc) {
+		return sc.StatusCode()
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return http.StatusRequestEntityTooLarge
+	}
+	if errors.Is(err, ErrBodyTimeout) {
+		return http.StatusRequestTimeout
+	}
+	return http.StatusBadRequest
+}
+
+// ErrPathValueFuncNotSet is returned by Path if no PathValueFunc is set.
+var ErrPathValueFuncNotSet = errors.New("bind: PathValueFunc not set")
+
+// ErrUnsupportedMediaType is returned by Body and DecodeBody for a content type
+// they can't decode. Handlers can respond with status 415 Unsupported Media
+// Type.
+var ErrUnsupportedMediaType = errors.New("bind: unsupported media type")
+
+// ErrBodyTooLarge matches every *BodyTooLargeError with errors.Is.
+var ErrBodyTooLarge = errors.New("bind: body too large")
+
+// BodyTooLargeError is returned when a body exceeds MaxBodyBytes. Err is the
+// underlying *http.MaxBytesError.
+type BodyTooLargeError struct {
+	Limit int64
+	Err   error
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("bind: body exceeds limit of %d bytes", e.Limit)
+}
+
+func (e *BodyTooLargeError) Unwrap() error {
+	return e.Err
+}
+
+func (e *BodyTooLargeError) Is(target error) bool {
+	return target == ErrBodyTooLarge
+}
+
+func (e *BodyTooLargeError) StatusCode() int {
+	return http.StatusRequestEntityTooLarge
+}
+
+// ErrBodyTimeout is returned by Body if reading the body takes longer than
+// BodyTimeout.
+var ErrBodyTimeout = errors.New("bind: body read timeout")
+
+// ErrUnsupportedContentEncoding matches every *UnsupportedContentEncodingError
+// with errors.Is.
+var ErrUnsupportedContentEncoding = errors.New("bind: unsupported content encoding")
+
+// UnsupportedContentEncodingError is returned for a body with a
+// Content-Encoding that isn't registered, see RegisterContentEncoding.
+type UnsupportedContentEncodingError struct {
+	Encoding string
+}
+
+func (e *UnsupportedContentEncodingError) Error() string {
+	return fmt.Sprintf("bind: unsupported content encoding %q", e.Encoding)
+}
+
+func (e *UnsupportedContentEncodingError) Is(target error) bool {
+	return target == ErrUnsupportedContentEncoding
+}
+
+func (e *UnsupportedContentEncodingError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+// ErrMissingField matches every *MissingFieldError with errors.Is.
+var ErrMissingField = errors.New("bind: missing required field")
+
+// MissingFieldError is returned when a field with a required:"true" tag is
+// absent or empty. Field is the name of the struct field, Name the name of
+// the parameter.
+type MissingFieldError struct {
+	Field string
+	Name  string
+}
+
+func (e *MissingFieldError) Error() string {
+	return fmt.Sprintf("bind: missing required field %s (%s)", e.Field, e.Name)
+}
+
+func (e *MissingFieldError) Is(target error) bool {
+	return target == ErrMissingField
+}
+
+func (e *MissingFieldError) StatusCode() int {
+	return http.StatusBadRequest
+}
+
+// ErrUnsupportedType matches every *UnsupportedTypeError and
+// *UnsupportedFieldError with errors.Is.
+var ErrUnsupportedType = errors.New("bind: unsupported type")
+
+// UnsupportedTypeError is returned when query, form, header or cookie values
+// are decoded into something other than a pointer to a struct or map. Only
+// JSON, XML, YAML and TOML bodies can be decoded into e.g. a top level slice.
+// As this points to a programming error it suggests status 500.
+type UnsupportedTypeError struct {
+	Type reflect.Type
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("bind: can't decode values into %s, need a pointer to a struct or map", e.Type)
+}
+
+func (e *UnsupportedTypeError) Is(target error) bool {
+	return target == ErrUnsupportedType
+}
+
+func (e *UnsupportedTypeError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// UnsupportedFieldError is returned when a field with a path, query, form,
+// header or cookie tag has a type that can't be bound from strings, like a
+// channel or func. As this points to a programming error it suggests status
+// 500. Use Check to catch these early.
+type UnsupportedFieldError struct {
+	Field string
+	Tag   string
+	Type  reflect.Type
+}
+
+func (e *UnsupportedFieldError) Error() string {
+	return fmt.Sprintf("bind: can't bind %s tagged field %s of unsupported type %s", e.Tag, e.Field, e.Type)
+}
+
+func (e *UnsupportedFieldError) Is(target error) bool {
+	return target == ErrUnsupportedType
+}
+
+func (e *UnsupportedFieldError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// InvalidTargetError is returned by Request if v is not a non-nil pointer to
+// a non-pointer value. Type is nil if v is nil. It suggests status 500.
+type InvalidTargetError struct {
+	Type   reflect.Type
+	Reason string
+}
+
+func (e *InvalidTargetError) Error() string {
+	if e.Type == nil {
+		return "bind: can't bind into nil"
+	}
+	return fmt.Sprintf("bind: can't bind into %s: %s", e.Type, e.Reason)
+}
+
+func (e *InvalidTargetError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// UnknownPathParamError is returned with the StrictPath flag for a path tag
+// whose param is absent from the route. As this points to a programming error
+// it suggests status 500.
+type UnknownPathParamError struct {
+	Field string
+	Name  string
+}
+
+func (e *UnknownPathParamError) Error() string {
+	return fmt.Sprintf("bind: unknown path param %s (%s)", e.Name, e.Field)
+}
+
+func (e *UnknownPathParamError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// FieldError is returned when a path or context value can't be set. Field is
+// the full Go path of the struct field, e.g. Base.ID, Name the name of the
+// parameter and Err the underlying error.
+type FieldError struct {
+	Field string
+	Name  string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("bind: field %s (%s): %s", e.Field, e.Name, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// RangeError is returned when a numeric value doesn't fit the type of its
+// field, e.g. 999 for an int8. Min and Max give the allowed range.
+type RangeError struct {
+	Value string
+	Type  string
+	Min   string
+	Max   string
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("bind: value %s out of range for %s, must be between %s and %s", e.Value, e.Type, e.Min, e.Max)
+}
+
+// ArrayLengthError is returned when more values are given than an array field
+// can hold.
+type ArrayLengthError struct {
+	Len int
+	Got int
+}
+
+func (e *ArrayLengthError) Error() string {
+	return fmt.Sprintf("bind: got %d values for array of length %d", e.Got, e.Len)
+}
+
+// UnsupportedMediaTypeError is returned for a body with a content type that
+// can't be decoded. It matches ErrUnsupportedMediaType with errors.Is.
+type UnsupportedMediaTypeError struct {
+	ContentType string
+}
+
+func (e *UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf("bind: unsupported media type %q", e.ContentType)
+}
+
+func (e *UnsupportedMediaTypeError) Is(target error) bool {
+	return target == ErrUnsupportedMediaType
+}
+
+func (e *UnsupportedMediaTypeError) StatusCode() int {
+	return http.StatusUnsupportedMediaType
+}
+
+// Errors collects multiple binding errors. It is only returned when more than
+// one field failed to bind, a single failure is returned as is.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Is reports whether any of the errors matches target. errors.Is only
+// follows Unwrap() []error since Go 1.20.
+func (e Errors) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches target, see Is.
+func (e Errors) As(target any) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e Errors) add(err error) Errors {
+	if errs, ok := err.(Errors); ok {
+		return append(e, errs...)
+	}
+	return append(e, err)
+}
+
+func (e Errors) err() error {
+	switch len(e) {
+	case 0:
+		return nil
+	case 1:
+		return e[0]
+	}
+	return e
+}
+
+// SourceError wraps errors returned by Request with the source that failed to
+// bind: "context", "path", "header", "cookie", "query" or "body".
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+// Error returns the message of the wrapped error with the source, e.g. "bind:
+// query: ...". The bind: prefix of the wrapped error is dropped so that it
+// isn't repeated.
+func (e *SourceError) Error() string {
+	return "bind: " + e.Source + ": " + strings.TrimPrefix(e.Err.Error(), "bind: ")
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// StatusCode returns the status code suggested by the wrapped error.
+func (e *SourceError) StatusCode() int {
+	return StatusCode(e.Err)
+}
+
+// ErrInvalidAuthorization matches every *AuthorizationError with errors.Is.
+var ErrInvalidAuthorization = errors.New("bind: invalid authorization")
+
+// AuthorizationError is returned by Authorization for a missing or malformed
+// Authorization header.
+type AuthorizationError struct {
+	Reason string
+}
+
+func (e *AuthorizationError) Error() string {
+	return "bind: " + e.Reason
+}
+
+func (e *AuthorizationError) Is(target error) bool {
+	return target == ErrInvalidAuthorization
+}
+
+func (e *AuthorizationError) StatusCode() int {
+	return http.StatusUnauthorized
+}
+
+// PanicError is returned by SafeRequest when binding panics. Value is the
+// recovered value and Stack the stack trace of the panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("bind: panic while binding: %v", e.Value)
+}
+
+// Unwrap returns the recovered value if it's an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+func (e *PanicError) StatusCode() int {
+	return http.StatusInternalServerError
+}
+
+// ValidationError wraps errors returned by a ValidateFunc to distinguish them
+// from binding errors.
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "bind: validation failed: " + e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func (e *ValidationError) StatusCode() int {
+	return http.StatusUnprocessableEntity
+}
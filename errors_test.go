@@ -0,0 +1,200 @@
+package bind
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStatusCode(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+		Page int    `query:"page" required:"true"`
+	}
+
+	newRequest := func(method, url, ct, body string) *http.Request {
+		r, _ := http.NewRequest(method, url, strings.NewReader(body))
+		r.Header.Set("Content-Type", ct)
+		return r
+	}
+
+	b := New()
+	b.MaxBodyBytes = 8
+	b.ValidateFunc = func(v any) error {
+		return errors.New("invalid")
+	}
+
+	tests := []struct {
+		r      *http.Request
+		status int
+	}{
+		{newRequest(http.MethodGet, "/", "", ""), http.StatusBadRequest},
+		{newRequest(http.MethodPost, "/", "application/cbor", "x"), http.StatusUnsupportedMediaType},
+		{newRequest(http.MethodPost, "/", "application/json", `{"name":"too large"}`), http.StatusRequestEntityTooLarge},
+		{newRequest(http.MethodPost, "/", "application/json", `{`), http.StatusBadRequest},
+		{newRequest(http.MethodGet, "/?page=1", "", ""), http.StatusUnprocessableEntity},
+	}
+
+	for _, test := range tests {
+		err := b.Request(test.r, &t1{})
+		if err == nil {
+			t.Errorf("%s %s: got nil, want error", test.r.Method, test.r.URL)
+		} else if got := StatusCode(err); got != test.status {
+			t.Errorf("%v: got %d, want %d", err, got, test.status)
+		}
+	}
+}
+
+func TestUnsupportedType(t *testing.T) {
+	vals := url.Values{"a": {"1"}}
+
+	var s []string
+	err := DecodeForm(vals, &s)
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want ErrUnsupportedType", err)
+	}
+	if StatusCode(err) != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", StatusCode(err))
+	}
+
+	var n int
+	if err := DecodeQuery(vals, &n); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("got %v, want ErrUnsupportedType", err)
+	}
+
+	m := map[string]int{}
+	if err := DecodeQuery(vals, &m); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestUnsupportedField(t *testing.T) {
+	type t1 struct {
+		Events chan int    `query:"events"`
+		Page   int         `query:"page"`
+		Hooks  []func()    `path:"hooks"`
+		Done   chan bool   `ctx:"done"`
+		Name   string      `form:"name"`
+		Fn     func() bool `form:"-"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?events=1&page=2", nil)
+
+	v := t1{}
+	err := Query(r, &v)
+	var fieldErr *UnsupportedFieldError
+	if !errors.As(err, &fieldErr) || !errors.Is(err, ErrUnsupportedType) {
+		t.Fatalf("got %v, want *UnsupportedFieldError", err)
+	}
+	if fieldErr.Field != "Events" || fieldErr.Tag != "query" {
+		t.Errorf("got %+v", fieldErr)
+	}
+	if want := "bind: can't bind query tagged field Events of unsupported type chan int"; err.Error() != want {
+		t.Errorf("got %q, want %q", err, want)
+	}
+	if StatusCode(err) != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", StatusCode(err))
+	}
+	if v.Page != 0 {
+		t.Errorf("got page %d, want nothing bound", v.Page)
+	}
+
+	var errs Errors
+	if err := Check(&v); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("got %v, want errors for Events and Hooks", err)
+	}
+	// Errors matches its errors without relying on Unwrap() []error
+	fieldErr = nil
+	if !errs.As(&fieldErr) || fieldErr.Field != "Hooks" {
+		t.Errorf("got %v, want the Hooks error", fieldErr)
+	}
+	if !(Errors{errors.New("x"), &MissingFieldError{}}).Is(ErrMissingField) {
+		t.Error("expected Errors to match ErrMissingField")
+	}
+
+	type t2 struct {
+		Page int    `query:"page"`
+		Name string `form:"name"`
+	}
+	if err := Check(&t2{}); err != nil {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestInvalidTarget(t *testing.T) {
+	type t1 struct {
+		Page int `query:"page"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=1", nil)
+
+	var nilPtr *t1
+	v := &t1{}
+	for _, target := range []any{nil, t1{}, nilPtr, &v} {
+		err := Request(r, target)
+		var e *InvalidTargetError
+		if !errors.As(err, &e) {
+			t.Errorf("%T: got %v, want *InvalidTargetError", target, err)
+		}
+	}
+
+	if err := Request(r, v); err != nil || v.Page != 1 {
+		t.Errorf("got %v and page %d", err, v.Page)
+	}
+
+	// maps don't receive headers or cookies
+	r.Header.Set("Authorization", "secret")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "secret"})
+	m := map[string]string{}
+	if err := Request(r, &m); err != nil || !reflect.DeepEqual(m, map[string]string{"page": "1"}) {
+		t.Errorf("got %v and map %v", err, m)
+	}
+
+	r, _ = http.NewRequest(http.MethodPost, "/?page=1", strings.NewReader(`[1,2]`))
+	r.Header.Set("Content-Type", "application/json")
+	var s []int
+	if err := Request(r, &s); err != nil || len(s) != 2 {
+		t.Errorf("got %v and slice %v", err, s)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	type t1 struct {
+		Page int `query:"page"`
+	}
+
+	type call struct {
+		source string
+		err    error
+	}
+	var calls []call
+	OnError = func(r *http.Request, source string, err error) {
+		calls = append(calls, call{source, err})
+	}
+	defer func() { OnError = nil }()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=x", nil)
+	err := Request(r, &t1{})
+	if len(calls) != 1 || calls[0].source != "query" {
+		t.Fatalf("got %v, want one query error", calls)
+	}
+	var se *SourceError
+	if !errors.As(err, &se) || se.Err.Error() != calls[0].err.Error() {
+		t.Errorf("got %v, want the reported error", err)
+	}
+
+	// validation errors are reported too
+	b := New()
+	b.ValidateFunc = func(v any) error {
+		return errors.New("invalid")
+	}
+	b.OnError = OnError
+	calls = nil
+	r, _ = http.NewRequest(http.MethodGet, "/?page=1", nil)
+	if err := b.Request(r, &t1{}); err == nil || len(calls) != 1 || calls[0].source != "validation" {
+		t.Errorf("got %v and calls %v", err, calls)
+	}
+}
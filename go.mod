@@ -2,4 +2,8 @@ module github.com/ugent-library/bind
 
 go 1.19
 
-require github.com/go-playground/form/v4 v4.2.0
+require (
+	github.com/BurntSushi/toml v1.3.2
+	github.com/go-playground/form/v4 v4.2.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,56 @@
+package bind
+
+import (
+	"net/http"
+)
+
+// MustRequest is like Request but panics if binding fails.
+func MustRequest(r *http.Request, v any, flags ...Flag) {
+	if err := Request(r, v, flags...); err != nil {
+		panic(err)
+	}
+}
+
+// SafeRequest is like Request but recovers from panics while binding and
+// returns them as a *PanicError. See Binder.SafeRequest.
+func SafeRequest(r *http.Request, v any, flags ...Flag) error {
+	return binder().SafeRequest(r, v, flags...)
+}
+
+// Handler returns a http.Handler that binds each request into a new T with
+// Request before calling fn. If binding fails, fn is not called and the
+// request is answered with the status code returned by StatusCode and the
+// error message, or the status text for server errors:
+//
+//	mux.Handle("/items", bind.Handler(func(w http.ResponseWriter, r *http.Request, v *ItemRequest) {
+//		...
+//	}))
+//
+// opts, including flags, are applied once to a Binder with the package level
+// settings at the time Handler is called, which is then used for every
+// request. Without opts the current package level settings are used.
+func Handler[T any](fn func(http.ResponseWriter, *http.Request, *T), opts ...Option) http.Handler {
+	var b *Binder
+	if len(opts) > 0 {
+		b = binder().With(opts...)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rb := b
+		if rb == nil {
+			rb = binder()
+		}
+		v := new(T)
+		err := rb.Request(r, v)
+		if err != nil {
+			// server errors are answered without details
+			code := StatusCode(err)
+			msg := err.Error()
+			if code >= http.StatusInternalServerError {
+				msg = http.StatusText(code)
+			}
+			http.Error(w, msg, code)
+			return
+		}
+		fn(w, r, v)
+	})
+}
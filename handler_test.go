@@ -0,0 +1,141 @@
+package bind
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMustRequest(t *testing.T) {
+	type t1 struct {
+		Page int `query:"page"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=2", nil)
+	v := t1{}
+	MustRequest(r, &v)
+	if v.Page != 2 {
+		t.Errorf("got %d, want %d", v.Page, 2)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected panic")
+		}
+	}()
+	r, _ = http.NewRequest(http.MethodGet, "/?page=x", nil)
+	MustRequest(r, &t1{})
+}
+
+type panicky string
+
+func (p *panicky) UnmarshalText(text []byte) error {
+	panic("can't unmarshal " + string(text))
+}
+
+func TestSafeRequest(t *testing.T) {
+	type t1 struct {
+		Page int     `query:"page"`
+		Bad  panicky `path:"bad"`
+	}
+
+	defer SetPathValueFunc(func(r *http.Request, k string) string {
+		return "x"
+	})()
+
+	r, _ := http.NewRequest(http.MethodGet, "/?page=2", nil)
+	err := SafeRequest(r, &t1{})
+	var e *PanicError
+	if !errors.As(err, &e) {
+		t.Fatalf("got %v, want *PanicError", err)
+	}
+	if e.Value != "can't unmarshal x" || len(e.Stack) == 0 {
+		t.Errorf("got %v", e.Value)
+	}
+	if StatusCode(err) != http.StatusInternalServerError {
+		t.Errorf("got status %d, want 500", StatusCode(err))
+	}
+
+	type t2 struct {
+		Page int `query:"page"`
+	}
+	v := t2{}
+	if err := SafeRequest(r, &v); err != nil || v.Page != 2 {
+		t.Errorf("got %v and page %d, want page 2", err, v.Page)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	type t1 struct {
+		Page int `query:"page"`
+	}
+
+	h := Handler(func(w http.ResponseWriter, r *http.Request, v *t1) {
+		if v.Page != 2 {
+			t.Errorf("got %d, want %d", v.Page, 2)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		method      string
+		url         string
+		contentType string
+		status      int
+	}{
+		{http.MethodGet, "/?page=2", "", http.StatusNoContent},
+		{http.MethodGet, "/?page=x", "", http.StatusBadRequest},
+		{http.MethodPost, "/", "application/cbor", http.StatusUnsupportedMediaType},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest(test.method, test.url, strings.NewReader("x"))
+		if test.contentType != "" {
+			r.Header.Set("Content-Type", test.contentType)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != test.status {
+			t.Errorf("%s %s: got %d, want %d", test.method, test.url, w.Code, test.status)
+		}
+	}
+
+	// server errors don't leak their details
+	type t3 struct {
+		Events chan string `query:"events"`
+	}
+	h3 := Handler(func(w http.ResponseWriter, r *http.Request, v *t3) {})
+	r, _ := http.NewRequest(http.MethodGet, "/?events=x", nil)
+	w := httptest.NewRecorder()
+	h3.ServeHTTP(w, r)
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("got %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if got, want := strings.TrimSpace(w.Body.String()), http.StatusText(http.StatusInternalServerError); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// options and flags are applied to every request
+	type t2 struct {
+		Name string `json:"name"`
+	}
+	h = Handler(func(w http.ResponseWriter, r *http.Request, v *t2) {
+		w.WriteHeader(http.StatusNoContent)
+	}, Strict, WithMaxBody(20))
+
+	for body, status := range map[string]int{
+		`{"name":"x"}`:                   http.StatusNoContent,
+		`{"name":"x","o":1}`:             http.StatusBadRequest,
+		`{"name":"xxxxxxxxxxxxxxxxxxx"}`: http.StatusRequestEntityTooLarge,
+	} {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != status {
+			t.Errorf("%s: got %d, want %d", body, w.Code, status)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package bind
+
+import (
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	fileHeaderType      = reflect.TypeOf((*multipart.FileHeader)(nil))
+	fileHeaderSliceType = reflect.TypeOf([]*multipart.FileHeader(nil))
+)
+
+type fileField struct {
+	index    []int
+	field    string
+	key      string
+	required bool
+}
+
+type fileFieldsCacheKey struct {
+	typ     reflect.Type
+	tagName string
+	ns      namespace
+}
+
+var fileFieldsCache sync.Map // map[fileFieldsCacheKey][]fileField
+
+// decodeFiles sets the form tagged *multipart.FileHeader and
+// []*multipart.FileHeader fields of v. Required fields without files result in
+// a *MissingFieldError.
+func (b *Binder) decodeFiles(files map[string][]*multipart.FileHeader, v any) error {
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	val = val.Elem()
+
+	var errs Errors
+	for _, f := range cachedFileFields(val.Type(), b.formDecoder.tagName, b.formDecoder.ns) {
+		fileHeaders := files[f.key]
+		if len(fileHeaders) == 0 {
+			fileHeaders = files[f.key+"[]"]
+		}
+		if len(fileHeaders) == 0 {
+			if f.required {
+				errs = errs.add(&MissingFieldError{Field: f.field, Name: f.key})
+			}
+			continue
+		}
+		fieldVal, ok := fieldByIndexAlloc(val, f.index)
+		if !ok {
+			continue
+		}
+		if fieldVal.Type() == fileHeaderType {
+			fieldVal.Set(reflect.ValueOf(fileHeaders[0]))
+		} else {
+			fieldVal.Set(reflect.ValueOf(fileHeaders))
+		}
+	}
+
+	return errs.err()
+}
+
+func cachedFileFields(t reflect.Type, tagName string, ns namespace) []fileField {
+	key := fileFieldsCacheKey{t, tagName, ns}
+	if fields, ok := fileFieldsCache.Load(key); ok {
+		return fields.([]fileField)
+	}
+	fields, _ := fileFieldsCache.LoadOrStore(key, fileFields(t, tagName, ns, nil, "", "", nil))
+	return fields.([]fileField)
+}
+
+// fileFields returns the file fields of t and of the structs nested in it.
+// Like valueFields, it doesn't descend into a struct type that is one of
+// parents.
+func fileFields(t reflect.Type, tagName string, ns namespace, index []int, fieldPrefix, parentKey string, parents []reflect.Type) []fileField {
+	var fields []fileField
+	parents = append(parents[:len(parents):len(parents)], t)
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldIndex := append(index[:len(index):len(index)], i)
+
+		name, _, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		if field.Type == fileHeaderType || field.Type == fileHeaderSliceType {
+			fields = append(fields, fileField{
+				index:    fieldIndex,
+				field:    fieldPrefix + field.Name,
+				key:      ns.key(parentKey, name),
+				required: isRequired(field.Tag),
+			})
+			continue
+		}
+
+		ft := field.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType && ft != fileHeaderType.Elem() && !isParentType(ft, parents) {
+			if field.Anonymous {
+				fields = append(fields, fileFields(ft, tagName, ns, fieldIndex, fieldPrefix, parentKey, parents)...)
+			} else {
+				fields = append(fields, fileFields(ft, tagName, ns, fieldIndex, fieldPrefix+field.Name+".", ns.key(parentKey, name), parents)...)
+			}
+		}
+	}
+
+	return fields
+}
+
+// CleanupMultipart removes the temporary files created while parsing a
+// multipart body. It's safe to call for any request:
+//
+//	defer bind.CleanupMultipart(r)
+func CleanupMultipart(r *http.Request) error {
+	if r.MultipartForm == nil {
+		return nil
+	}
+	return r.MultipartForm.RemoveAll()
+}
@@ -0,0 +1,109 @@
+package bind
+
+import "time"
+
+// Option configures a Binder, see New and Binder.With. Options complement
+// flags for settings that need a value. Flags implement Option as well, a
+// flag passed to New applies to every call. Build the Binder once and reuse
+// it:
+//
+//	var uploads = bind.New(bind.WithMaxBody(100<<20), bind.Vacuum)
+//
+//	err := uploads.Request(r, &v)
+type Option interface {
+	apply(*Binder)
+}
+
+type optionFunc func(*Binder)
+
+func (fn optionFunc) apply(b *Binder) {
+	fn(b)
+}
+
+// apply adds f to the flags of b, see Binder.Flags.
+func (f Flag) apply(b *Binder) {
+	b.Flags = append(b.Flags, f)
+}
+
+// WithMaxBody sets the body size limit, see Binder.MaxBodyBytes.
+func WithMaxBody(n int64) Option {
+	return optionFunc(func(b *Binder) {
+		b.MaxBodyBytes = n
+	})
+}
+
+// WithMaxMultipartMemory sets the multipart memory limit, see
+// Binder.MaxMultipartMemory.
+func WithMaxMultipartMemory(n int64) Option {
+	return optionFunc(func(b *Binder) {
+		b.MaxMultipartMemory = n
+	})
+}
+
+// WithBodyTimeout sets the body read timeout, see Binder.BodyTimeout.
+func WithBodyTimeout(d time.Duration) Option {
+	return optionFunc(func(b *Binder) {
+		b.BodyTimeout = d
+	})
+}
+
+// WithSources restricts Request to the given sources, e.g. to ignore
+// client-supplied query parameters:
+//
+//	bind.New(bind.WithSources(bind.SourcePath | bind.SourceBody))
+//
+// SourceBody covers all body content types, including forms, SourceForm only
+// URL encoded and multipart form bodies. By default all sources are bound.
+func WithSources(s Source) Option {
+	return optionFunc(func(b *Binder) {
+		b.sources = s
+	})
+}
+
+// WithMaxArraySize sets the largest slice or array index that is accepted in
+// query, form, header and cookie keys like tags[9999]. Larger indices return
+// an error instead of allocating a huge slice. The default is
+// DefaultMaxArraySize.
+func WithMaxArraySize(n int) Option {
+	return optionFunc(func(b *Binder) {
+		b.setMaxArraySize(n)
+	})
+}
+
+// WithSeparator sets the default delimiter of path slice values. The delim tag
+// takes precedence. The default is ",".
+func WithSeparator(sep string) Option {
+	return optionFunc(func(b *Binder) {
+		b.separator = sep
+	})
+}
+
+// WithTimeFormat sets the default layout of time values. The format tag takes
+// precedence. The default is time.RFC3339. Like in format tags, the special
+// layouts "unix" and "unixmilli" accept Unix timestamps in seconds and
+// milliseconds.
+func WithTimeFormat(layout string) Option {
+	return optionFunc(func(b *Binder) {
+		b.setTimeFormat(layout)
+	})
+}
+
+// WithQueryTagFallback binds query parameters to struct fields without a query
+// tag by their tagName tag, e.g. to bind the json tagged models that are shared
+// with the body from the query string:
+//
+//	bind.New(bind.WithQueryTagFallback("json"))
+//
+// Query tags take precedence. The fallback is off by default.
+func WithQueryTagFallback(tagName string) Option {
+	return optionFunc(func(b *Binder) {
+		b.queryDecoder.setFallbackTag(tagName)
+	})
+}
+
+// WithFlags adds flags that are applied to every call, see Binder.Flags.
+func WithFlags(flags ...Flag) Option {
+	return optionFunc(func(b *Binder) {
+		b.Flags = append(b.Flags, flags...)
+	})
+}
@@ -0,0 +1,248 @@
+package bind
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-playground/form/v4"
+)
+
+func TestOptions(t *testing.T) {
+	type t1 struct {
+		IDs  []int     `path:"ids"`
+		Tags []string  `path:"tags" delim:","`
+		Day  time.Time `path:"day" query:"day"`
+	}
+
+	b := New(WithSeparator(";"), WithTimeFormat("2006-01-02"))
+	b.PathValueFunc = func(r *http.Request, k string) string {
+		switch k {
+		case "ids":
+			return "1;2"
+		case "tags":
+			return "a,b"
+		case "day":
+			return "2024-03-01"
+		}
+		return ""
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?day=2024-03-02", nil)
+
+	v := t1{}
+	if err := b.Path(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(v.IDs, []int{1, 2}) || !reflect.DeepEqual(v.Tags, []string{"a", "b"}) {
+		t.Errorf("got %v and %v", v.IDs, v.Tags)
+	}
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !v.Day.Equal(want) {
+		t.Errorf("got %v, want %v", v.Day, want)
+	}
+
+	// options are kept by clones
+	v = t1{}
+	if err := b.Clone().Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC); !v.Day.Equal(want) {
+		t.Errorf("got %v, want %v", v.Day, want)
+	}
+
+	// the encode side uses them too, so that Path can bind the result
+	v = t1{IDs: []int{1, 2}, Tags: []string{"a", "b"}, Day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)}
+	params, err := b.EncodePath(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"ids": "1;2", "tags": "a,b", "day": "2024-03-01"}; !reflect.DeepEqual(params, want) {
+		t.Errorf("got %v, want %v", params, want)
+	}
+	u, err := b.EncodeURL("/items/{ids}", &struct {
+		IDs []int `path:"ids"`
+	}{IDs: []int{1, 2}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/items/1%3B2"; u != want {
+		t.Errorf("got %q, want %q", u, want)
+	}
+}
+
+func TestWith(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	b := New()
+	small := b.With(WithMaxBody(4), WithFlags(Strict))
+
+	if b.MaxBodyBytes != DefaultMaxBodyBytes || len(b.Flags) != 0 {
+		t.Error("expected With to leave the original Binder untouched")
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"x"}`))
+	r.Header.Set("Content-Type", "application/json")
+	var maxBytesErr *http.MaxBytesError
+	if err := small.Body(r, &t1{}); !errors.As(err, &maxBytesErr) {
+		t.Errorf("got %v, want *http.MaxBytesError", err)
+	}
+
+	// flags are options too
+	if b := New(Vacuum); len(b.Flags) != 1 || b.Flags[0] != Vacuum {
+		t.Errorf("got %v, want [Vacuum]", b.Flags)
+	}
+}
+
+func TestWithSources(t *testing.T) {
+	type t1 struct {
+		ID    int    `path:"id"`
+		Token string `header:"X-Token"`
+		Name  string `json:"name"`
+	}
+
+	newRequest := func() *http.Request {
+		r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"x"}`))
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("X-Token", "secret")
+		return r
+	}
+	pathValue := func(r *http.Request, k string) string {
+		return "1"
+	}
+
+	b := New(WithSources(SourcePath | SourceBody))
+	b.PathValueFunc = pathValue
+	v := t1{}
+	if err := b.Request(newRequest(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (t1{ID: 1, Name: "x"}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	b = New(WithSources(SourcePath | SourceHeader))
+	b.PathValueFunc = pathValue
+	v = t1{}
+	if err := b.Clone().Request(newRequest(), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := (t1{ID: 1, Token: "secret"}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+
+	// SourceForm only enables form bodies
+	type t2 struct {
+		Name string `json:"name" form:"name"`
+	}
+
+	b = New(WithSources(SourceForm))
+	v2 := t2{}
+	if err := b.Request(newRequest(), &v2); err != nil {
+		t.Fatal(err)
+	}
+	if v2.Name != "" {
+		t.Errorf("got %q, want empty string", v2.Name)
+	}
+
+	r, _ := http.NewRequest(http.MethodPost, "/", strings.NewReader("name=y"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := b.Request(r, &v2); err != nil {
+		t.Fatal(err)
+	}
+	if v2.Name != "y" {
+		t.Errorf("got %q, want %q", v2.Name, "y")
+	}
+}
+
+func TestWithQueryTagFallback(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+	}
+	type t1 struct {
+		ID      int      `json:"id"`
+		Name    string   `json:"name" query:"q"`
+		Tags    []string `json:"tags"`
+		Address address  `json:"address"`
+		Secret  string   `json:"-"`
+	}
+
+	r, _ := http.NewRequest(http.MethodGet, "/?id=5&q=x&name=y&tags=a&tags=b&address.city=Gent&Secret=s", nil)
+
+	v := t1{}
+	if err := New().Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 0 || v.Name != "x" {
+		t.Errorf("fallback should be off by default, got %+v", v)
+	}
+
+	b := New(WithQueryTagFallback("json"))
+	v = t1{}
+	if err := b.Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	want := t1{ID: 5, Name: "x", Tags: []string{"a", "b"}, Address: address{City: "Gent"}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+	if b.Sources(&v)&SourceQuery == 0 {
+		t.Error("expected query source")
+	}
+
+	v = t1{}
+	if err := b.Clone().Query(r, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.ID != 5 {
+		t.Errorf("clone lost the fallback, got %+v", v)
+	}
+}
+
+func TestWithMaxArraySize(t *testing.T) {
+	type item struct {
+		Name string `query:"name"`
+	}
+	type t1 struct {
+		Tags  []string `query:"tags"`
+		Items []item   `query:"items"`
+	}
+
+	b := New(WithMaxArraySize(10))
+
+	v := t1{}
+	if err := b.DecodeQuery(url.Values{"tags[9]": {"a"}, "items[9].name": {"b"}}, &v); err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Tags) != 10 || len(v.Items) != 10 {
+		t.Errorf("got %d tags and %d items, want 10", len(v.Tags), len(v.Items))
+	}
+
+	for _, vals := range []url.Values{
+		{"tags[10]": {"a"}},
+		{"tags[1000000]": {"a"}},
+		{"items[10].name": {"b"}},
+	} {
+		v := t1{}
+		err := b.DecodeQuery(vals, &v)
+		var errs form.DecodeErrors
+		if !errors.As(err, &errs) {
+			t.Errorf("%v: got %v, want decode errors", vals, err)
+		}
+		if len(v.Tags) != 0 || len(v.Items) != 0 {
+			t.Errorf("%v: got %+v, want nothing allocated", vals, v)
+		}
+	}
+
+	if err := b.Clone().DecodeQuery(url.Values{"tags[10]": {"a"}}, &t1{}); err == nil {
+		t.Error("clone lost the max array size")
+	}
+	if err := New().DecodeQuery(url.Values{"tags[10]": {"a"}}, &t1{}); err != nil {
+		t.Errorf("got %v with the default max array size", err)
+	}
+}
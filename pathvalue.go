@@ -0,0 +1,9 @@
+//go:build !go1.22
+
+package bind
+
+import "net/http"
+
+// defaultPathValueFunc is nil before Go 1.22, PathValueFunc has to be set to
+// use path binding.
+var defaultPathValueFunc func(*http.Request, string) string
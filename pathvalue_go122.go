@@ -0,0 +1,10 @@
+//go:build go1.22
+
+package bind
+
+import "net/http"
+
+// defaultPathValueFunc looks up path wildcards matched by http.ServeMux.
+func defaultPathValueFunc(r *http.Request, k string) string {
+	return r.PathValue(k)
+}
@@ -0,0 +1,67 @@
+//go:build go1.22
+
+// the module's go version would otherwise select the Go 1.21 ServeMux patterns
+//go:debug httpmuxgo121=0
+
+package bind
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDefaultPathValueFunc(t *testing.T) {
+	type t1 struct {
+		ID   int    `path:"id"`
+		Page int    `query:"page"`
+		Name string `path:"name"`
+	}
+
+	defer ResetPathValueFunc()
+	ResetPathValueFunc()
+
+	var v t1
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}/{name}", func(w http.ResponseWriter, r *http.Request) {
+		if err := Request(r, &v); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/items/12/x?page=2", nil)
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	if want := (t1{ID: 12, Page: 2, Name: "x"}); v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
+
+func TestEncodeURLServeMux(t *testing.T) {
+	type t1 struct {
+		ID   int    `path:"id"`
+		Path string `path:"path"`
+	}
+
+	defer ResetPathValueFunc()
+	ResetPathValueFunc()
+
+	var v t1
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}/{path...}", func(w http.ResponseWriter, r *http.Request) {
+		if err := Request(r, &v); err != nil {
+			t.Error(err)
+		}
+	})
+
+	want := t1{ID: 12, Path: "a b/c.txt"}
+	u, err := EncodeURL("/items/{id}/{path...}", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, u, nil))
+
+	if v != want {
+		t.Errorf("got %+v, want %+v", v, want)
+	}
+}
@@ -0,0 +1,132 @@
+package bind
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// FieldSet holds the keys that were present in a body. Keys of nested JSON
+// objects are joined with a dot, e.g. address.city.
+type FieldSet map[string]struct{}
+
+// Has reports whether key was present.
+func (s FieldSet) Has(key string) bool {
+	_, ok := s[key]
+	return ok
+}
+
+// BodyWithPresence is like Body but also returns the keys present in a JSON,
+// URL encoded form or multipart form body. This makes it possible to
+// distinguish absent fields from zero values, e.g. for PATCH requests:
+//
+//	present, err := bind.BodyWithPresence(r, &v)
+//	if present.Has("name") {
+//		...
+//	}
+//
+// The keys of a multipart body include its files. The returned set is empty
+// for other content types.
+func (b *Binder) BodyWithPresence(r *http.Request, v any, flags ...Flag) (FieldSet, error) {
+	present := FieldSet{}
+
+	// other empty bodies are detected under the body timeout
+	if r.Body == nil || r.Body == http.NoBody {
+		return present, nil
+	}
+
+	allFlags := b.flags(flags)
+	ct := r.Header.Get("Content-Type")
+
+	// content types are dispatched like in Body, keys of bodies decoded by a
+	// registered decoder are unknown
+	switch {
+	case b.bodyDecoder(ct) != nil:
+	case strings.HasPrefix(ct, "multipart/form-data"):
+		if err := b.Body(r, v, flags...); err != nil {
+			return nil, err
+		}
+		if r.MultipartForm == nil {
+			return present, nil
+		}
+		for k := range r.MultipartForm.Value {
+			present[k] = struct{}{}
+		}
+		for k := range r.MultipartForm.File {
+			present[k] = struct{}{}
+		}
+		return present, nil
+	case strings.HasPrefix(ct, "application/x-www-form-urlencoded") && !hasFlag(allFlags, LiteralPlus):
+		// Body parses the form, so the keys are in r.PostForm
+		if err := b.Body(r, v, flags...); err != nil {
+			return nil, err
+		}
+		for k := range r.PostForm {
+			present[k] = struct{}{}
+		}
+		return present, nil
+	case strings.HasPrefix(ct, "application/json") || strings.HasPrefix(ct, "application/x-www-form-urlencoded"):
+		// the body is buffered so that its keys can be read after binding
+		body, err := b.readBody(r, allFlags)
+		if err != nil {
+			return nil, err
+		}
+		if body == nil {
+			return present, nil
+		}
+		if err := b.DecodeBody(bytes.NewReader(body), ct, v, flags...); err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(ct, "application/json") {
+			addJSONKeys(present, body, "")
+			return present, nil
+		}
+		// only reached with LiteralPlus
+		vals, err := parseQuery(string(body), true)
+		if err != nil {
+			return nil, err
+		}
+		for k := range vals {
+			present[k] = struct{}{}
+		}
+		return present, nil
+	}
+
+	if err := b.Body(r, v, flags...); err != nil {
+		return nil, err
+	}
+	return present, nil
+}
+
+// readBody reads the whole body of r like Body does, with the timeout, size
+// limit and decompression applied. It returns nil for an empty body.
+func (b *Binder) readBody(r *http.Request, flags []Flag) ([]byte, error) {
+	ok, closeBody, err := b.openBody(r, flags)
+	if !ok {
+		return nil, err
+	}
+	defer closeBody()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	if hasFlag(flags, RewindBody) {
+		rewindBody(r, body)
+	}
+	return body, nil
+}
+
+// addJSONKeys adds the keys of JSON object data and its nested objects to s.
+func addJSONKeys(s FieldSet, data []byte, prefix string) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return
+	}
+	for k, v := range obj {
+		s[prefix+k] = struct{}{}
+		addJSONKeys(s, v, prefix+k+".")
+	}
+}
@@ -0,0 +1,138 @@
+package bind
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBodyWithPresence(t *testing.T) {
+	type address struct {
+		City string `json:"city"`
+		Zip  string `json:"zip"`
+	}
+	type t1 struct {
+		Name    string  `json:"name" form:"name"`
+		Age     int     `json:"age" form:"age"`
+		Address address `json:"address"`
+	}
+
+	r, _ := http.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"age":0,"address":{"city":"Ghent"}}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	v := t1{Name: "x"}
+	present, err := BodyWithPresence(r, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "x" || v.Address.City != "Ghent" {
+		t.Errorf("got %+v", v)
+	}
+	for _, key := range []string{"age", "address", "address.city"} {
+		if !present.Has(key) {
+			t.Errorf("expected %q to be present", key)
+		}
+	}
+	for _, key := range []string{"name", "address.zip"} {
+		if present.Has(key) {
+			t.Errorf("expected %q to be absent", key)
+		}
+	}
+
+	r, _ = http.NewRequest(http.MethodPatch, "/", strings.NewReader("age=3"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	v = t1{}
+	present, err = BodyWithPresence(r, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Age != 3 || !present.Has("age") || present.Has("name") {
+		t.Errorf("got %+v and %v", v, present)
+	}
+
+	// the keys keep a literal plus, like the values
+	r, _ = http.NewRequest(http.MethodPatch, "/", strings.NewReader("age=3&a+b=x"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	v = t1{}
+	present, err = BodyWithPresence(r, &v, LiteralPlus, RewindBody)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Age != 3 || !present.Has("a+b") || present.Has("a b") {
+		t.Errorf("got %+v and %v", v, present)
+	}
+	if body, _ := io.ReadAll(r.Body); string(body) != "age=3&a+b=x" {
+		t.Errorf("got body %q after rewind", body)
+	}
+}
+
+func TestBodyWithPresenceHeaders(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name"`
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"name":"x"}`))
+	zw.Close()
+
+	r, _ := http.NewRequest(http.MethodPatch, "/", bytes.NewReader(gz.Bytes()))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Content-Encoding", "gzip")
+	r.Header.Set("Content-Length", strconv.Itoa(gz.Len()))
+
+	v := t1{}
+	present, err := BodyWithPresence(r, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Name != "x" || !present.Has("name") {
+		t.Errorf("got %+v and %v", v, present)
+	}
+	// the request is left alone without RewindBody
+	if r.Header.Get("Content-Encoding") != "gzip" || r.Header.Get("Content-Length") != strconv.Itoa(gz.Len()) {
+		t.Errorf("got headers %v", r.Header)
+	}
+
+	r, _ = http.NewRequest(http.MethodPatch, "/", &bytes.Buffer{})
+	r.Header.Set("Content-Type", "application/json")
+	if present, err := BodyWithPresence(r, &v); err != nil || len(present) != 0 {
+		t.Errorf("got %v and %v, want nil and no keys", err, present)
+	}
+}
+
+func TestBodyWithPresenceMultipart(t *testing.T) {
+	type t1 struct {
+		Name   string                `form:"name"`
+		Age    int                   `form:"age"`
+		Avatar *multipart.FileHeader `form:"avatar"`
+	}
+
+	body := &bytes.Buffer{}
+	mw := multipart.NewWriter(body)
+	mw.WriteField("name", "x")
+	fw, _ := mw.CreateFormFile("avatar", "avatar.png")
+	fw.Write([]byte("png"))
+	mw.Close()
+
+	r, _ := http.NewRequest(http.MethodPatch, "/", body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+
+	v := t1{}
+	present, err := BodyWithPresence(r, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer CleanupMultipart(r)
+	if v.Name != "x" || v.Avatar == nil {
+		t.Errorf("got %+v", v)
+	}
+	if !present.Has("name") || !present.Has("avatar") || present.Has("age") {
+		t.Errorf("got %v", present)
+	}
+}
@@ -0,0 +1,82 @@
+package bind
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Respond encodes v as JSON or XML depending on the request's Accept header
+// and writes it with the given status code. The supported type with the
+// highest q value wins. JSON is used when the Accept header is missing,
+// accepts anything or doesn't mention a supported type.
+func Respond(w http.ResponseWriter, r *http.Request, status int, v any) error {
+	if negotiate(r.Header.Get("Accept")) == "application/xml" {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(status)
+		if _, err := w.Write([]byte(xml.Header)); err != nil {
+			return err
+		}
+		return xml.NewEncoder(w).Encode(v)
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	return json.NewEncoder(w).Encode(v)
+}
+
+// negotiate returns the most preferred supported media type in accept.
+func negotiate(accept string) string {
+	for _, mediaType := range ParseAccept(accept) {
+		switch mediaType {
+		case "application/json", "application/*", "*/*":
+			return "application/json"
+		case "application/xml", "text/xml":
+			return "application/xml"
+		}
+	}
+	return "application/json"
+}
+
+// ParseAccept returns the media types in an Accept header sorted by their q
+// value, most preferred first. Media types with the same q value keep their
+// order. A missing or malformed q value counts as 1, media types with q=0 and
+// malformed media types are left out.
+func ParseAccept(header string) []string {
+	type entry struct {
+		mediaType string
+		q         float64
+	}
+
+	var entries []entry
+	for _, part := range strings.Split(header, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+				q = f
+			}
+		}
+		if q == 0 {
+			continue
+		}
+		entries = append(entries, entry{mediaType, q})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].q > entries[j].q
+	})
+
+	mediaTypes := make([]string, len(entries))
+	for i, e := range entries {
+		mediaTypes[i] = e.mediaType
+	}
+	return mediaTypes
+}
@@ -0,0 +1,73 @@
+package bind
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRespond(t *testing.T) {
+	type t1 struct {
+		Name string `json:"name" xml:"name"`
+	}
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"", "application/json; charset=utf-8", `{"name":"x"}`},
+		{"*/*", "application/json; charset=utf-8", `{"name":"x"}`},
+		{"text/html, application/xml;q=0.9", "application/xml; charset=utf-8", `<t1><name>x</name></t1>`},
+		{"text/html", "application/json; charset=utf-8", `{"name":"x"}`},
+	}
+
+	for _, test := range tests {
+		r, _ := http.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Accept", test.accept)
+		w := httptest.NewRecorder()
+		if err := Respond(w, r, http.StatusCreated, t1{Name: "x"}); err != nil {
+			t.Error(err)
+			continue
+		}
+		if w.Code != http.StatusCreated {
+			t.Errorf("got %d, want %d", w.Code, http.StatusCreated)
+		}
+		if got := w.Header().Get("Content-Type"); got != test.contentType {
+			t.Errorf("accept %q: got %q, want %q", test.accept, got, test.contentType)
+		}
+		if got := w.Body.String(); !strings.Contains(got, test.body) {
+			t.Errorf("accept %q: got %q, want %q", test.accept, got, test.body)
+		}
+	}
+}
+
+func TestParseAccept(t *testing.T) {
+	tests := []struct {
+		header string
+		want   []string
+	}{
+		{"", []string{}},
+		{"application/json", []string{"application/json"}},
+		{"text/html, application/xml;q=0.9, */*;q=0.8", []string{"text/html", "application/xml", "*/*"}},
+		{"application/json;q=0.5, application/xml", []string{"application/xml", "application/json"}},
+		{"text/plain;q=0.5, text/html;q=0.5, text/csv;q=0.7", []string{"text/csv", "text/plain", "text/html"}},
+		{"application/json;q=x, application/xml;q=0.9", []string{"application/json", "application/xml"}},
+		{"application/json;q=2, text/xml;q=0.1", []string{"application/json", "text/xml"}},
+		{"application/json;q=0, text/xml", []string{"text/xml"}},
+		{"garbage;;, Text/XML", []string{"text/xml"}},
+	}
+
+	for _, test := range tests {
+		if got := ParseAccept(test.header); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %v, want %v", test.header, got, test.want)
+		}
+	}
+
+	// negotiation honors q values
+	if got := negotiate("application/json;q=0.5, application/xml"); got != "application/xml" {
+		t.Errorf("got %s, want application/xml", got)
+	}
+}
@@ -0,0 +1,227 @@
+package bind
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/go-playground/form/v4"
+)
+
+// Source is a bitmask of the parts of a request a value is bound from.
+type Source uint
+
+const (
+	SourceContext Source = 1 << iota
+	SourcePath
+	SourceHeader
+	SourceCookie
+	SourceQuery
+	SourceForm
+	SourceBody
+)
+
+// AllSources has every Source set.
+const AllSources = SourceContext | SourcePath | SourceHeader | SourceCookie | SourceQuery | SourceForm | SourceBody
+
+var sourceNames = []string{"context", "path", "header", "cookie", "query", "form", "body"}
+
+// Has reports whether all sources in o are set in s.
+func (s Source) Has(o Source) bool {
+	return s&o == o
+}
+
+// String returns the source names joined by "|", e.g. path|query.
+func (s Source) String() string {
+	var names []string
+	for i, name := range sourceNames {
+		if s.Has(1 << i) {
+			names = append(names, name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+type sourcesCacheKey struct {
+	typ      reflect.Type
+	queryTag string
+	// queryFallbackTag is set by WithQueryTagFallback
+	queryFallbackTag string
+	formTag          string
+	headerTag        string
+	cookieTag        string
+	ns               namespace
+	mode             form.Mode
+}
+
+var sourcesCache sync.Map // map[sourcesCacheKey]Source
+
+// Sources returns the sources v has tagged fields for. Request skips the path,
+// header, cookie and query passes for sources that v doesn't use. If the mode
+// isn't form.ModeExplicit, the header, cookie, query and form sources are
+// always included since untagged fields are bound too. Maps are only bound
+// from the query or a form body, so that headers and cookies don't end up in
+// them. Other values that aren't structs, like slices, interfaces and
+// scalars, are only bound from a body, so no source is returned for them.
+// Body binding doesn't depend on tags and isn't included.
+func (b *Binder) Sources(v any) Source {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t != nil && t.Kind() == reflect.Map {
+		return SourceQuery | SourceForm
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return 0
+	}
+
+	key := sourcesCacheKey{
+		typ:              t,
+		queryTag:         b.queryDecoder.tagName,
+		queryFallbackTag: b.queryDecoder.fallbackTag,
+		formTag:          b.formDecoder.tagName,
+		headerTag:        b.headerDecoder.tagName,
+		cookieTag:        b.cookieDecoder.tagName,
+		ns:               b.ns,
+		mode:             b.mode,
+	}
+	if s, ok := sourcesCache.Load(key); ok {
+		return s.(Source)
+	}
+
+	var s Source
+	if len(cachedTagFields(t, "ctx")) > 0 {
+		s |= SourceContext
+	}
+	if len(cachedTagFields(t, "path")) > 0 {
+		s |= SourcePath
+	}
+	for _, d := range []struct {
+		dec    *valuesDecoder
+		source Source
+	}{
+		{b.headerDecoder, SourceHeader},
+		{b.cookieDecoder, SourceCookie},
+		{b.queryDecoder, SourceQuery},
+		{b.formDecoder, SourceForm},
+	} {
+		// in implicit mode untagged fields are bound by their name as well
+		if b.mode != form.ModeExplicit || len(d.dec.typeFields(t)) > 0 {
+			s |= d.source
+		}
+	}
+	if len(cachedFileFields(t, b.formDecoder.tagName, b.formDecoder.ns)) > 0 {
+		s |= SourceForm
+	}
+
+	sourcesCache.Store(key, s)
+	return s
+}
+
+// enabledSources returns the sources set with WithSources, all sources by
+// default.
+func (b *Binder) enabledSources() Source {
+	if b.sources == 0 {
+		return AllSources
+	}
+	return b.sources
+}
+
+// Describe returns a table of the fields of v with the source, name and type
+// they are bound from, one field per line, e.g. to find out why a field isn't
+// populated. The body isn't included since its binding doesn't depend on
+// tags.
+func (b *Binder) Describe(v any) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return ""
+	}
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FIELD\tSOURCE\tNAME\tTYPE")
+	for _, d := range []struct {
+		tagName string
+		source  Source
+	}{
+		{"ctx", SourceContext},
+		{"path", SourcePath},
+	} {
+		for _, f := range cachedTagFields(t, d.tagName) {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.field, d.source, f.name, typeByIndex(t, f.index))
+		}
+	}
+	for _, d := range []struct {
+		dec    *valuesDecoder
+		source Source
+	}{
+		{b.headerDecoder, SourceHeader},
+		{b.cookieDecoder, SourceCookie},
+		{b.queryDecoder, SourceQuery},
+		{b.formDecoder, SourceForm},
+	} {
+		for _, f := range d.dec.typeFields(t) {
+			name := f.key
+			if f.raw {
+				name = "(raw)"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.field, d.source, name, typeByIndex(t, f.index))
+		}
+	}
+	for _, f := range cachedFileFields(t, b.formDecoder.tagName, b.formDecoder.ns) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.field, SourceForm, f.key, typeByIndex(t, f.index))
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// Check returns an error for every field of v with a path, query, form,
+// header or cookie tag that has a type that can't be bound, like a channel or
+// func, see UnsupportedFieldError. Request returns the same errors, Check
+// makes it possible to catch them in a test or at startup:
+//
+//	if err := b.Check(&CreateUserRequest{}); err != nil {
+//		log.Fatal(err)
+//	}
+func (b *Binder) Check(v any) error {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var errs Errors
+	for _, f := range cachedTagFields(t, "path") {
+		if f.unsupported {
+			errs = errs.add(&UnsupportedFieldError{Field: f.field, Tag: "path", Type: typeByIndex(t, f.index)})
+		}
+	}
+	for _, d := range b.valuesDecoders() {
+		for _, f := range d.typeFields(t) {
+			if f.unsupported {
+				errs = errs.add(&UnsupportedFieldError{Field: f.field, Tag: d.tagName, Type: typeByIndex(t, f.index)})
+			}
+		}
+	}
+	return errs.err()
+}
+
+// typeByIndex is like reflect.Type.FieldByIndex but steps through pointers to
+// embedded or nested structs.
+func typeByIndex(t reflect.Type, index []int) reflect.Type {
+	for _, i := range index {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		t = t.Field(i).Type
+	}
+	return t
+}
@@ -0,0 +1,103 @@
+package bind
+
+import (
+	"mime/multipart"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/form/v4"
+)
+
+func TestSources(t *testing.T) {
+	type inner struct {
+		Token string `header:"X-Token"`
+	}
+	type t1 struct {
+		ID   int `path:"id"`
+		Page int `query:"page"`
+	}
+	type t2 struct {
+		Inner inner `header:"inner"`
+		Name  string
+	}
+	type t3 struct {
+		Name string `json:"name"`
+	}
+
+	tests := []struct {
+		v    any
+		want Source
+	}{
+		{&t1{}, SourcePath | SourceQuery},
+		{&t2{}, SourceHeader},
+		{&t3{}, 0},
+		{&map[string]string{}, SourceQuery | SourceForm},
+		{&[]t1{}, 0},
+		{new(any), 0},
+		{new(int), 0},
+	}
+
+	for _, test := range tests {
+		if got := Sources(test.v); got != test.want {
+			t.Errorf("%T: got %s, want %s", test.v, got, test.want)
+		}
+	}
+
+	// the analysis depends on the tag names
+	b := New()
+	b.SetQueryTagName("json")
+	if got := b.Sources(&t3{}); got != SourceQuery {
+		t.Errorf("got %s, want %s", got, SourceQuery)
+	}
+
+	// untagged fields are bound in implicit mode
+	b = New()
+	b.SetMode(form.ModeImplicit)
+	if got, want := b.Sources(&t3{}), SourceHeader|SourceCookie|SourceQuery|SourceForm; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	if got := (SourcePath | SourceQuery).String(); got != "path|query" {
+		t.Errorf("got %q, want %q", got, "path|query")
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	type t1 struct {
+		ID    int      `path:"id"`
+		Page  *int     `query:"page"`
+		Tags  []string `query:"tags" form:"tags"`
+		Token string   `header:"X-Token"`
+	}
+
+	want := `FIELD  SOURCE  NAME     TYPE
+ID     path    id       int
+Token  header  X-Token  string
+Page   query   page     *int
+Tags   query   tags     []string
+Tags   form    tags     []string
+`
+	if got := Describe(&t1{}); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// files are listed as form fields, but aren't bound as values
+	type t2 struct {
+		Name   string                `form:"name"`
+		Avatar *multipart.FileHeader `form:"avatar" default:"x"`
+	}
+	want = `FIELD   SOURCE  NAME    TYPE
+Name    form    name    string
+Avatar  form    avatar  *multipart.FileHeader
+`
+	if got := Describe(&t2{}); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if fields := Default().formDecoder.typeFields(reflect.TypeOf(t2{})); len(fields) != 1 {
+		t.Errorf("got %d value fields, want 1", len(fields))
+	}
+
+	if got := Describe(map[string]string{}); got != "" {
+		t.Errorf("got %q, want empty string", got)
+	}
+}